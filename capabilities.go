@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Capabilities describe the features of the SQLite library behind a Dialector,
+// they are detected once by Initialize from the version and the compile options.
+type Capabilities struct {
+	Version string
+	// Returning supports the RETURNING clause, since 3.35.0.
+	Returning bool
+	// UpdateFrom supports UPDATE FROM, since 3.33.0.
+	UpdateFrom bool
+	// DropColumn supports ALTER TABLE DROP COLUMN, since 3.35.0.
+	DropColumn bool
+	// RenameColumn supports ALTER TABLE RENAME COLUMN, since 3.25.0.
+	RenameColumn bool
+	// JSON has the JSON functions, built in since 3.38.0 unless omitted, a compile option before.
+	JSON bool
+	// JSONB has the binary JSON functions, since 3.45.0.
+	JSONB bool
+	FTS5  bool
+	RTree bool
+	// MathFunctions has the built-in math functions, since 3.35.0 when enabled at compile time.
+	MathFunctions bool
+	// StrictTables supports STRICT tables, since 3.37.0.
+	StrictTables bool
+}
+
+func detectCapabilities(ctx context.Context, pool gorm.ConnPool) (Capabilities, error) {
+	var caps Capabilities
+	if err := pool.QueryRowContext(ctx, "select sqlite_version()").Scan(&caps.Version); err != nil {
+		return caps, err
+	}
+
+	options := map[string]bool{}
+	rows, err := pool.QueryContext(ctx, "SELECT compile_options FROM pragma_compile_options")
+	if err != nil {
+		return caps, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var option string
+		if err := rows.Scan(&option); err != nil {
+			return caps, err
+		}
+		// e.g. MAX_ATTACHED=10
+		options[strings.SplitN(option, "=", 2)[0]] = true
+	}
+	if err := rows.Err(); err != nil {
+		return caps, err
+	}
+
+	since := func(version string) bool {
+		return compareVersion(caps.Version, version) >= 0
+	}
+
+	// https://www.sqlite.org/changes.html
+	caps.Returning = since("3.35.0")
+	caps.UpdateFrom = since("3.33.0")
+	caps.DropColumn = since("3.35.0")
+	caps.RenameColumn = since("3.25.0")
+	caps.JSON = (since("3.38.0") && !options["OMIT_JSON"]) || options["ENABLE_JSON1"]
+	caps.JSONB = caps.JSON && since("3.45.0")
+	caps.FTS5 = options["ENABLE_FTS5"]
+	caps.RTree = options["ENABLE_RTREE"]
+	caps.MathFunctions = since("3.35.0") && options["ENABLE_MATH_FUNCTIONS"]
+	caps.StrictTables = since("3.37.0")
+	return caps, nil
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CloseTimeout bounds how long Close waits for the transactions in flight.
+var CloseTimeout = 30 * time.Second
+
+// Close runs CloseContext, waiting at most CloseTimeout for the transactions in flight.
+func Close(db *gorm.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CloseTimeout)
+	defer cancel()
+	return CloseContext(ctx, db)
+}
+
+// CloseContext waits for the transactions in flight, runs PRAGMA optimize and truncates the WAL after
+// checkpointing it into the database file, then closes the pool. Without it the next start has to
+// replay whatever is left in the -wal file. When ctx is done before the transactions end, the pool is
+// closed without the checkpoint and the error of ctx is returned.
+func CloseContext(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	dialector := dialectorOf(db)
+	if dialector != nil && dialector.transactions != nil {
+		if err := dialector.transactions.wait(ctx); err != nil {
+			return errors.Join(fmt.Errorf("sqlite: waiting for transactions: %w", err), sqlDB.Close())
+		}
+	}
+
+	if dialector == nil || !dialector.readOnly() {
+		if _, err := sqlDB.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+			errs = append(errs, err)
+		}
+
+		var busy, logFrames, checkpointed int
+		if err := sqlDB.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed); err != nil {
+			errs = append(errs, err)
+		} else if busy != 0 {
+			errs = append(errs, fmt.Errorf("sqlite: wal checkpoint blocked, %d of %d frames checkpointed", checkpointed, logFrames))
+		}
+	}
+
+	errs = append(errs, sqlDB.Close())
+	return errors.Join(errs...)
+}
+
+// transactions counts the transactions in flight on the connections opened by a Dialector.
+type transactions struct {
+	mu    sync.Mutex
+	count int
+	// idle is closed when count drops back to zero
+	idle chan struct{}
+}
+
+func (t *transactions) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+}
+
+func (t *transactions) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count--; t.count == 0 {
+		close(t.idle)
+	}
+}
+
+// wait blocks until no transaction is in flight, or ctx is done.
+func (t *transactions) wait(ctx context.Context) error {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackedTx ends its transaction in the counter once committed or rolled back.
+type trackedTx struct {
+	driver.Tx
+	once sync.Once
+	end  func()
+}
+
+func (t *trackedTx) Commit() error {
+	defer t.once.Do(t.end)
+	return t.Tx.Commit()
+}
+
+func (t *trackedTx) Rollback() error {
+	defer t.once.Do(t.end)
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CollateUnicodeNoCase compares strings ignoring case with Unicode simple case folding,
+// unlike the built-in NOCASE collation which only folds ASCII letters.
+func CollateUnicodeNoCase(left, right string) int {
+	for left != "" && right != "" {
+		l, lSize := utf8.DecodeRuneInString(left)
+		r, rSize := utf8.DecodeRuneInString(right)
+		if l, r = foldRune(l), foldRune(r); l != r {
+			if l < r {
+				return -1
+			}
+			return 1
+		}
+		left, right = left[lSize:], right[rSize:]
+	}
+	return compareLength(left, right)
+}
+
+// CollateNatural compares strings the way people sort them, runs of digits are compared by their numeric value,
+// so "file2" sorts before "file10".
+func CollateNatural(left, right string) int {
+	originLeft, originRight := left, right
+	for left != "" && right != "" {
+		if isDigit(left[0]) && isDigit(right[0]) {
+			lDigits, rDigits := digitsPrefix(left), digitsPrefix(right)
+			lNumber, rNumber := strings.TrimLeft(lDigits, "0"), strings.TrimLeft(rDigits, "0")
+			if len(lNumber) != len(rNumber) {
+				return compareLength(lNumber, rNumber)
+			}
+			if c := strings.Compare(lNumber, rNumber); c != 0 {
+				return c
+			}
+			left, right = left[len(lDigits):], right[len(rDigits):]
+			continue
+		}
+
+		l, lSize := utf8.DecodeRuneInString(left)
+		r, rSize := utf8.DecodeRuneInString(right)
+		if l != r {
+			if l < r {
+				return -1
+			}
+			return 1
+		}
+		left, right = left[lSize:], right[rSize:]
+	}
+
+	if c := compareLength(left, right); c != 0 {
+		return c
+	}
+	// e.g. "a01" and "a1", keep the order total
+	return strings.Compare(originLeft, originRight)
+}
+
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func digitsPrefix(str string) string {
+	i := 0
+	for i < len(str) && isDigit(str[i]) {
+		i++
+	}
+	return str[:i]
+}
+
+func compareLength(left, right string) int {
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestCollateUnicodeNoCase(t *testing.T) {
+	params := []struct {
+		left, right string
+		expect      int
+	}{
+		{"straße", "STRASSE", 1},
+		{"Ärger", "ärger", 0},
+		{"Ωmega", "ωMEGA", 0},
+		{"abc", "abd", -1},
+		{"ab", "abc", -1},
+	}
+	for _, p := range params {
+		if got := CollateUnicodeNoCase(p.left, p.right); got != p.expect {
+			t.Errorf("CollateUnicodeNoCase(%q, %q) = %d, expect %d", p.left, p.right, got, p.expect)
+		}
+	}
+}
+
+func TestCollateNatural(t *testing.T) {
+	params := []struct {
+		left, right string
+		expect      int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file02", "file2", -1},
+		{"file2", "file2", 0},
+		{"v1.10.0", "v1.9.3", 1},
+		{"a", "a1", -1},
+	}
+	for _, p := range params {
+		if got := CollateNatural(p.left, p.right); got != p.expect {
+			t.Errorf("CollateNatural(%q, %q) = %d, expect %d", p.left, p.right, got, p.expect)
+		}
+	}
+}
+
+func TestCollations(t *testing.T) {
+	type CollatedItem struct {
+		ID   uint
+		Name string `gorm:"collate:test_unicode_nocase"`
+		Code string `gorm:"index:idx_collated_items_code,collate:test_natural"`
+	}
+
+	db, err := gorm.Open(New(Config{
+		DSN: "file:collationsdatabase?mode=memory&cache=shared",
+		Collations: map[string]func(left, right string) int{
+			"test_unicode_nocase": CollateUnicodeNoCase,
+			"test_natural":        CollateNatural,
+		},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&CollatedItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create([]CollatedItem{{Name: "Ärger", Code: "item10"}, {Name: "Öl", Code: "item2"}}).Error; err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	var item CollatedItem
+	if err := db.Where("name = ?", "ärger").First(&item).Error; err != nil {
+		t.Errorf("Expected column collation to ignore unicode case; got error: %v", err)
+	}
+
+	var codes []string
+	db.Model(&CollatedItem{}).Order("code COLLATE test_natural").Pluck("code", &codes)
+	tests.AssertEqual(t, codes, []string{"item2", "item10"})
+
+	var indexSQL string
+	db.Raw("SELECT sql FROM sqlite_master WHERE name = ?", "idx_collated_items_code").Scan(&indexSQL)
+	tests.AssertEqual(t, indexSQL, "CREATE INDEX `idx_collated_items_code` ON `collated_items`(`code` COLLATE test_natural)")
+}
+
+func TestCollationsReplaced(t *testing.T) {
+	// closures made from the same literal share their code, they must still be told apart
+	suffixFirst := func(suffix string) func(left, right string) int {
+		return func(left, right string) int {
+			if strings.HasSuffix(left, suffix) != strings.HasSuffix(right, suffix) {
+				if strings.HasSuffix(left, suffix) {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(left, right)
+		}
+	}
+
+	for _, suffix := range []string{"-de", "-sv"} {
+		db, err := gorm.Open(New(Config{
+			DSN:        "file:collationsreplaced?mode=memory&cache=shared",
+			Collations: map[string]func(left, right string) int{"test_suffix_first": suffixFirst(suffix)},
+		}), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Expected Open to succeed; got error: %v", err)
+		}
+
+		var first string
+		db.Raw("SELECT v FROM (SELECT 'a-de' AS v UNION ALL SELECT 'b-sv') ORDER BY v COLLATE test_suffix_first LIMIT 1").Scan(&first)
+		if !strings.HasSuffix(first, suffix) {
+			t.Errorf("Expected the collation of %v to be used, got %q first", suffix, first)
+		}
+		Close(db)
+	}
+}
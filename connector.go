@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	msqlite "modernc.org/sqlite"
+)
+
+// connector opens connections through the registered driver and prepares each of them
+// before database/sql puts it into the pool, so the setup survives pool churn.
+type connector struct {
+	base    driver.Connector
+	pragmas []string
+	txLock  TxLock
+	// attach maps aliases to database files, they are attached before the pragmas run
+	attach map[string]string
+	// onConnect runs last, on a *sql.Conn bound to the new connection
+	onConnect func(ctx context.Context, conn *sql.Conn) error
+	// txs counts the transactions in flight for Close, it's shared by the connectors of a Dialector
+	txs *transactions
+	// closers are released together with the *sql.DB built on this connector
+	closers []io.Closer
+}
+
+func newConnector(driverName, dsn string) (*connector, error) {
+	// sql.Open doesn't connect, it only resolves the registered driver
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	if driverCtx, ok := drv.(driver.DriverContext); ok {
+		base, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{base: base}, nil
+	}
+	return &connector{base: dsnConnector{driver: drv, dsn: dsn}}, nil
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	driverConn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]string, 0, len(c.attach))
+	for alias := range c.attach {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if err := execConn(ctx, driverConn, "ATTACH DATABASE ? AS ?",
+			driver.NamedValue{Ordinal: 1, Value: c.attach[alias]}, driver.NamedValue{Ordinal: 2, Value: alias},
+		); err != nil {
+			driverConn.Close()
+			return nil, fmt.Errorf("sqlite: attach %v: %w", alias, err)
+		}
+	}
+
+	for _, pragma := range c.pragmas {
+		if err := execConn(ctx, driverConn, pragma); err != nil {
+			driverConn.Close()
+			return nil, err
+		}
+	}
+
+	conn := &conn{Conn: driverConn, txLock: c.txLock, txs: c.txs}
+	if c.onConnect != nil {
+		if err := c.runOnConnect(ctx, conn); err != nil {
+			driverConn.Close()
+			return nil, fmt.Errorf("sqlite: on connect: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// runOnConnect hands the connection to the hook through a throwaway *sql.DB, which can't close it.
+func (c *connector) runOnConnect(ctx context.Context, conn *conn) error {
+	db := sql.OpenDB(borrowedConnector{conn: borrowedConn{conn}, driver: c.Driver()})
+	defer db.Close()
+
+	sqlConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sqlConn.Close()
+
+	return c.onConnect(ctx, sqlConn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// Close is called by database/sql when the *sql.DB using this connector is closed.
+func (c *connector) Close() error {
+	var errs []error
+	for _, closer := range c.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// conn wraps a driver connection to control how transactions begin, the other calls are passed through.
+type conn struct {
+	driver.Conn
+	txLock TxLock
+	txs    *transactions
+}
+
+// Unwrap returns the driver connection.
+func (c *conn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// Serialize, Deserialize, NewBackup, NewRestore and FileControlPersistWAL forward the extensions of
+// the modernc.org/sqlite connection, so they stay reachable through sql.Conn.Raw.
+func (c *conn) Serialize() ([]byte, error) {
+	if serializer, ok := c.Conn.(interface{ Serialize() ([]byte, error) }); ok {
+		return serializer.Serialize()
+	}
+	return nil, errNotSupported("Serialize")
+}
+
+func (c *conn) Deserialize(buf []byte) error {
+	if deserializer, ok := c.Conn.(interface{ Deserialize(buf []byte) error }); ok {
+		return deserializer.Deserialize(buf)
+	}
+	return errNotSupported("Deserialize")
+}
+
+func (c *conn) NewBackup(dstUri string) (*msqlite.Backup, error) {
+	if backuper, ok := c.Conn.(interface {
+		NewBackup(dstUri string) (*msqlite.Backup, error)
+	}); ok {
+		return backuper.NewBackup(dstUri)
+	}
+	return nil, errNotSupported("NewBackup")
+}
+
+func (c *conn) NewRestore(srcUri string) (*msqlite.Backup, error) {
+	if restorer, ok := c.Conn.(interface {
+		NewRestore(srcUri string) (*msqlite.Backup, error)
+	}); ok {
+		return restorer.NewRestore(srcUri)
+	}
+	return nil, errNotSupported("NewRestore")
+}
+
+func (c *conn) FileControlPersistWAL(dbName string, mode int) (int, error) {
+	if fileControl, ok := c.Conn.(msqlite.FileControl); ok {
+		return fileControl.FileControlPersistWAL(dbName, mode)
+	}
+	return 0, errNotSupported("FileControlPersistWAL")
+}
+
+func errNotSupported(method string) error {
+	return fmt.Errorf("sqlite: %v is not supported by the driver connection", method)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := beginTx(ctx, c.Conn, c.txLock, opts)
+	if err != nil || c.txs == nil {
+		return tx, err
+	}
+
+	c.txs.begin()
+	return &trackedTx{Tx: tx, end: c.txs.end}, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+type borrowedConn struct {
+	*conn
+}
+
+func (borrowedConn) Close() error {
+	return nil
+}
+
+type borrowedConnector struct {
+	conn   borrowedConn
+	driver driver.Driver
+}
+
+func (c borrowedConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.conn, nil
+}
+
+func (c borrowedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// execConn runs a statement on a driver connection, which is not yet owned by database/sql.
+func execConn(ctx context.Context, conn driver.Conn, query string, args ...driver.NamedValue) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		if _, err := execer.ExecContext(ctx, query, args); err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, args)
+		return err
+	}
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	_, err = stmt.Exec(values)
+	return err
+}
@@ -23,12 +23,15 @@ var (
 	columnRegexp       = regexp.MustCompile(fmt.Sprintf(`^[%v]?([\w\d]+)[%v]?\s+([\w\(\)\d]+)(.*)$`, sqliteSeparator, sqliteSeparator))
 	defaultValueRegexp = regexp.MustCompile(`(?i) DEFAULT \(?(.+)?\)?( |COLLATE|GENERATED|$)`)
 	regRealDataType    = regexp.MustCompile(`[^\d](\d+)[^\d]?`)
+	triggerOfRegexp    = regexp.MustCompile(`(?is)(\sUPDATE\s+OF\s+)(.+?)(\s+ON\s)`)
 )
 
 type ddl struct {
 	head    string
 	fields  []string
 	columns []migrator.ColumnType
+	// renamed maps renamed columns to their previous name, to copy the rows of a recreated table
+	renamed map[string]string
 }
 
 func parseDDL(strs ...string) (*ddl, error) {
@@ -187,6 +190,10 @@ func (d *ddl) clone() *ddl {
 	copy(copied.fields, d.fields)
 	copied.columns = make([]migrator.ColumnType, len(d.columns))
 	copy(copied.columns, d.columns)
+	copied.renamed = make(map[string]string, len(d.renamed))
+	for column, previous := range d.renamed {
+		copied.renamed[column] = previous
+	}
 
 	return copied
 }
@@ -205,7 +212,8 @@ func (d *ddl) renameTable(dst, src string) error {
 		return err
 	}
 
-	replaced := tableReg.ReplaceAllString(d.head, fmt.Sprintf(" `%s` ", dst))
+	// dst may be qualified with the schema of an attached database
+	replaced := tableReg.ReplaceAllString(d.head, fmt.Sprintf(" `%s` ", strings.ReplaceAll(dst, ".", "`.`")))
 	if replaced == d.head {
 		return fmt.Errorf("failed to look up tablename `%s` from DDL head '%s'", src, d.head)
 	}
@@ -214,8 +222,9 @@ func (d *ddl) renameTable(dst, src string) error {
 	return nil
 }
 
+// compileConstraintRegexp matches the definition of the named constraint, identifiers are case insensitive.
 func compileConstraintRegexp(name string) *regexp.Regexp {
-	return regexp.MustCompile("^(?i:CONSTRAINT)\\s+[\"`]?" + regexp.QuoteMeta(name) + "[\"`\\s]")
+	return regexp.MustCompile("^(?i)CONSTRAINT\\s+[\"`\\[]?" + regexp.QuoteMeta(name) + "[\"`\\]\\s]")
 }
 
 func (d *ddl) addConstraint(name string, sql string) {
@@ -291,3 +300,56 @@ func (d *ddl) removeColumn(name string) bool {
 
 	return false
 }
+
+// renameColumn renames the definition of the column and its references in table constraints.
+func (d *ddl) renameColumn(oldName, newName string) bool {
+	var (
+		definition = regexp.MustCompile("^" + quotedIdentifier(oldName) + `(\s|$)`)
+		renamed    bool
+	)
+
+	for i, field := range d.fields {
+		upper := strings.ToUpper(field)
+		switch {
+		case strings.HasPrefix(upper, "PRIMARY KEY") || strings.HasPrefix(upper, "FOREIGN KEY") ||
+			checkRegexp.MatchString(field) || constraintRegexp.MatchString(field) || uniqueRegexp.MatchString(field):
+			d.fields[i] = renameReferences(field, oldName, newName)
+		case definition.MatchString(field):
+			d.fields[i] = definition.ReplaceAllString(field, "`"+newName+"`${1}")
+			renamed = true
+		}
+	}
+
+	if renamed {
+		if d.renamed == nil {
+			d.renamed = map[string]string{}
+		}
+		if previous, ok := d.renamed[oldName]; ok {
+			oldName = previous
+		}
+		d.renamed[newName] = oldName
+	}
+	return renamed
+}
+
+func quotedIdentifier(name string) string {
+	return fmt.Sprintf("[%v]?%v[%v]?", sqliteSeparator, regexp.QuoteMeta(name), sqliteSeparator)
+}
+
+// renameReferences renames the column references in a list of columns or an expression, e.g. of an index.
+func renameReferences(sql, oldName, newName string) string {
+	reference := regexp.MustCompile(`(^|[\s(,])` + quotedIdentifier(oldName) + `([\s),]|$)`)
+	return reference.ReplaceAllString(sql, "${1}`"+newName+"`${2}")
+}
+
+// renameTriggerReferences renames the references of a trigger to a column of its table, which are the columns
+// of UPDATE OF and those of the NEW and OLD rows. The other references can't be told from those to the columns
+// of other tables, they are left as is.
+func renameTriggerReferences(sql, oldName, newName string) string {
+	sql = triggerOfRegexp.ReplaceAllStringFunc(sql, func(head string) string {
+		matches := triggerOfRegexp.FindStringSubmatch(head)
+		return matches[1] + renameReferences(matches[2], oldName, newName) + matches[3]
+	})
+	row := regexp.MustCompile(`(?i)\b(NEW|OLD)\.` + quotedIdentifier(oldName) + `(\W|$)`)
+	return row.ReplaceAllString(sql, "${1}.`"+newName+"`${2}")
+}
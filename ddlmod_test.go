@@ -450,3 +450,56 @@ func TestGetColumns(t *testing.T) {
 		})
 	}
 }
+
+func TestRenameColumn(t *testing.T) {
+	params := []struct {
+		name    string
+		ddl     string
+		oldName string
+		newName string
+		success bool
+		expect  []string
+	}{
+		{
+			name:    "with_constraints",
+			ddl:     "CREATE TABLE `notes` (`id` integer NOT NULL,`user_id` integer,`user_id2` integer,PRIMARY KEY (`id`),CONSTRAINT `fk_users_notes` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `chk_user` CHECK (user_id > 0))",
+			oldName: "user_id",
+			newName: "owner_id",
+			success: true,
+			expect:  []string{"`id` integer NOT NULL", "`owner_id` integer", "`user_id2` integer", "PRIMARY KEY (`id`)", "CONSTRAINT `fk_users_notes` FOREIGN KEY (`owner_id`) REFERENCES `users`(`id`)", "CONSTRAINT `chk_user` CHECK (`owner_id` > 0)"},
+		},
+		{
+			name:    "unquoted",
+			ddl:     "CREATE TABLE Persons (ID int NOT NULL,Age int,UNIQUE (ID, Age))",
+			oldName: "Age",
+			newName: "Years",
+			success: true,
+			expect:  []string{"ID int NOT NULL", "`Years` int", "UNIQUE (ID, `Years`)"},
+		},
+		{
+			name:    "missing",
+			ddl:     "CREATE TABLE Persons (ID int NOT NULL)",
+			oldName: "Age",
+			newName: "Years",
+			success: false,
+			expect:  []string{"ID int NOT NULL"},
+		},
+	}
+
+	for _, p := range params {
+		t.Run(p.name, func(t *testing.T) {
+			testDDL, err := parseDDL(p.ddl)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			success := testDDL.renameColumn(p.oldName, p.newName)
+
+			tests.AssertEqual(t, p.success, success)
+			tests.AssertEqual(t, p.expect, testDDL.fields)
+			if success {
+				tests.AssertEqual(t, map[string]string{p.newName: p.oldName}, testDDL.renamed)
+			}
+		})
+	}
+}
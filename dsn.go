@@ -0,0 +1,250 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DSN is a modernc.org/sqlite connection string in a structured form, use String to render it for Open.
+// See https://www.sqlite.org/uri.html and https://pkg.go.dev/modernc.org/sqlite#Driver.Open
+type DSN struct {
+	// Path is the database file, or the name of a shared in-memory database when Mode is "memory".
+	Path string
+	// Memory opens a private in-memory database, Path is ignored.
+	Memory bool
+	// Mode is one of ro, rw, rwc or memory.
+	Mode string
+	// Cache is shared or private.
+	Cache     string
+	VFS       string
+	Immutable bool
+	// Pragmas are run on every connection, e.g. "foreign_keys(1)" or "journal_mode=WAL".
+	Pragmas []string
+	// TimeFormat is the format used to write time values, only "sqlite" is supported.
+	TimeFormat string
+	TxLock     TxLock
+	// Params keeps the parameters not covered by the fields above, Validate rejects those SQLite doesn't know.
+	Params url.Values
+}
+
+const memoryPath = ":memory:"
+
+// ParseDSN parses a connection string the way modernc.org/sqlite does.
+func ParseDSN(dsn string) (*DSN, error) {
+	var (
+		result   DSN
+		path     = dsn
+		rawQuery string
+	)
+
+	if pos := strings.IndexByte(dsn, '?'); pos >= 0 {
+		path, rawQuery = dsn[:pos], dsn[pos+1:]
+	}
+
+	if strings.HasPrefix(path, "file:") {
+		path = strings.TrimPrefix(path, "file:")
+		// file://host/path, only an empty host or localhost is allowed
+		if strings.HasPrefix(path, "//") {
+			path = strings.TrimPrefix(path[2:], "localhost")
+		}
+		unescaped, err := url.PathUnescape(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dsn path %q: %w", path, err)
+		}
+		path = unescaped
+	}
+
+	if path == memoryPath {
+		result.Memory = true
+	} else {
+		result.Path = path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn query %q: %w", rawQuery, err)
+	}
+
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch key {
+		case "mode":
+			result.Mode = value
+		case "cache":
+			result.Cache = value
+		case "vfs":
+			result.VFS = value
+		case "immutable":
+			result.Immutable = value == "1"
+		case "_pragma":
+			result.Pragmas = append(result.Pragmas, values...)
+		case "_time_format":
+			result.TimeFormat = value
+		case "_txlock":
+			result.TxLock = TxLock(value)
+		default:
+			if result.Params == nil {
+				result.Params = url.Values{}
+			}
+			result.Params[key] = values
+		}
+	}
+
+	return &result, nil
+}
+
+// String renders the DSN, as a file: URI when it has parameters only understood in that form.
+func (d DSN) String() string {
+	path := d.Path
+	if d.Memory {
+		path = memoryPath
+	}
+
+	var params []string
+	add := func(key, value string) {
+		params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+
+	if d.Mode != "" {
+		add("mode", d.Mode)
+	}
+	if d.Cache != "" {
+		add("cache", d.Cache)
+	}
+	if d.VFS != "" {
+		add("vfs", d.VFS)
+	}
+	if d.Immutable {
+		add("immutable", "1")
+	}
+	uri := len(params) > 0
+
+	keys := make([]string, 0, len(d.Params))
+	for key := range d.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range d.Params[key] {
+			add(key, value)
+		}
+	}
+
+	for _, pragma := range d.Pragmas {
+		add("_pragma", pragma)
+	}
+	if d.TimeFormat != "" {
+		add("_time_format", d.TimeFormat)
+	}
+	if d.TxLock != "" {
+		add("_txlock", string(d.TxLock))
+	}
+
+	if uri {
+		path = "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+	}
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// Validate reports unknown parameters, invalid values and mistyped pragmas,
+// which SQLite would otherwise silently ignore.
+func (d DSN) Validate() error {
+	var errs []error
+
+	for key := range d.Params {
+		if !knownParams[key] {
+			errs = append(errs, fmt.Errorf("unknown dsn parameter %q", key))
+		}
+	}
+
+	switch d.Mode {
+	case "", "ro", "rw", "rwc", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("invalid dsn mode %q", d.Mode))
+	}
+
+	switch d.Cache {
+	case "", "shared", "private":
+	default:
+		errs = append(errs, fmt.Errorf("invalid dsn cache %q", d.Cache))
+	}
+
+	if d.TimeFormat != "" && d.TimeFormat != "sqlite" {
+		errs = append(errs, fmt.Errorf("invalid dsn _time_format %q", d.TimeFormat))
+	}
+
+	if _, err := d.TxLock.validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, pragma := range d.Pragmas {
+		if name, _ := splitPragma(pragma); !knownPragmas[name] {
+			errs = append(errs, fmt.Errorf("unknown pragma %q", pragma))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Pragma returns the value set by the last _pragma with the given name.
+func (d DSN) Pragma(name string) (value string, ok bool) {
+	for _, pragma := range d.Pragmas {
+		if pragmaName, pragmaValue := splitPragma(pragma); pragmaName == strings.ToLower(name) {
+			value, ok = pragmaValue, true
+		}
+	}
+	return
+}
+
+// isTruthy reports whether a boolean pragma value turns the setting on.
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.Trim(value, `'"`)) {
+	case "1", "on", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// splitPragma splits "foreign_keys(1)", "journal_mode = WAL" or "main.cache_size=-2000" into name and value.
+func splitPragma(pragma string) (name, value string) {
+	name = strings.TrimSpace(pragma)
+	if pos := strings.IndexAny(name, "(= "); pos >= 0 {
+		name, value = name[:pos], strings.TrimSpace(name[pos:])
+		value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+		if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+			value = strings.TrimSpace(value[1 : len(value)-1])
+		}
+	}
+	if pos := strings.LastIndexByte(name, '.'); pos >= 0 {
+		name = name[pos+1:]
+	}
+	return strings.ToLower(name), value
+}
+
+// https://www.sqlite.org/uri.html#recognized_query_parameters, those without a field in DSN
+var knownParams = map[string]bool{"modeof": true, "nolock": true, "psow": true}
+
+// https://www.sqlite.org/pragma.html#toc
+var knownPragmas = map[string]bool{
+	"analysis_limit": true, "application_id": true, "auto_vacuum": true, "automatic_index": true,
+	"busy_timeout": true, "cache_size": true, "cache_spill": true, "case_sensitive_like": true,
+	"cell_size_check": true, "checkpoint_fullfsync": true, "collation_list": true, "compile_options": true,
+	"data_version": true, "database_list": true, "defer_foreign_keys": true, "encoding": true,
+	"foreign_key_check": true, "foreign_key_list": true, "foreign_keys": true, "freelist_count": true,
+	"fullfsync": true, "function_list": true, "hard_heap_limit": true, "ignore_check_constraints": true,
+	"incremental_vacuum": true, "index_info": true, "index_list": true, "index_xinfo": true,
+	"integrity_check": true, "journal_mode": true, "journal_size_limit": true, "legacy_alter_table": true,
+	"legacy_file_format": true, "locking_mode": true, "max_page_count": true, "mmap_size": true,
+	"module_list": true, "optimize": true, "page_count": true, "page_size": true, "pragma_list": true,
+	"query_only": true, "quick_check": true, "read_uncommitted": true, "recursive_triggers": true,
+	"reverse_unordered_selects": true, "secure_delete": true, "shrink_memory": true, "soft_heap_limit": true,
+	"synchronous": true, "table_info": true, "table_list": true, "table_xinfo": true, "temp_store": true,
+	"threads": true, "trusted_schema": true, "user_version": true, "wal_autocheckpoint": true,
+	"wal_checkpoint": true, "writable_schema": true,
+}
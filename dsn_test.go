@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"net/url"
+	"testing"
+
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestParseDSN(t *testing.T) {
+	params := []struct {
+		name   string
+		dsn    string
+		expect DSN
+		render string
+	}{
+		{"plain_file", "gorm.db", DSN{Path: "gorm.db"}, "gorm.db"},
+		{"memory", ":memory:", DSN{Memory: true}, ":memory:"},
+		{
+			"shared_memory",
+			"file:testdatabase?mode=memory&cache=shared",
+			DSN{Path: "testdatabase", Mode: "memory", Cache: "shared"},
+			"file:testdatabase?mode=memory&cache=shared",
+		},
+		{
+			"pragmas",
+			"gorm.db?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate",
+			DSN{Path: "gorm.db", Pragmas: []string{"foreign_keys(1)", "journal_mode(WAL)"}, TimeFormat: "sqlite", TxLock: "immediate"},
+			"gorm.db?_pragma=foreign_keys%281%29&_pragma=journal_mode%28WAL%29&_time_format=sqlite&_txlock=immediate",
+		},
+		{
+			"read_only_uri",
+			"file:///var/lib/app%3fdata.db?mode=ro&immutable=1&vfs=unix-none",
+			DSN{Path: "/var/lib/app?data.db", Mode: "ro", VFS: "unix-none", Immutable: true},
+			"file:/var/lib/app%3fdata.db?mode=ro&vfs=unix-none&immutable=1",
+		},
+		{
+			"unknown_param",
+			"file:gorm.db?mode=rwc&_pragmas=foreign_keys(1)",
+			DSN{Path: "gorm.db", Mode: "rwc", Params: url.Values{"_pragmas": {"foreign_keys(1)"}}},
+			"file:gorm.db?mode=rwc&_pragmas=foreign_keys%281%29",
+		},
+	}
+
+	for _, p := range params {
+		t.Run(p.name, func(t *testing.T) {
+			dsn, err := ParseDSN(p.dsn)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", p.dsn, err)
+			}
+			tests.AssertEqual(t, *dsn, p.expect)
+			tests.AssertEqual(t, dsn.String(), p.render)
+
+			again, err := ParseDSN(dsn.String())
+			if err != nil {
+				t.Fatalf("failed to parse rendered %q: %v", dsn.String(), err)
+			}
+			tests.AssertEqual(t, *again, p.expect)
+		})
+	}
+}
+
+func TestDSNValidate(t *testing.T) {
+	params := []struct {
+		name  string
+		dsn   DSN
+		valid bool
+	}{
+		{"empty", DSN{}, true},
+		{"pragmas", DSN{Path: "gorm.db", Pragmas: []string{"foreign_keys(1)", "main.journal_mode = WAL", "busy_timeout=5000"}}, true},
+		{"mistyped_pragma", DSN{Path: "gorm.db", Pragmas: []string{"foreign_key(1)"}}, false},
+		{"unknown_param", DSN{Path: "gorm.db", Params: url.Values{"_foreign_keys": {"1"}}}, false},
+		{"uri_params", DSN{Path: "gorm.db", Params: url.Values{"nolock": {"1"}, "psow": {"0"}, "modeof": {"other.db"}}}, true},
+		{"invalid_mode", DSN{Path: "gorm.db", Mode: "readonly"}, false},
+		{"invalid_cache", DSN{Path: "gorm.db", Cache: "none"}, false},
+		{"invalid_time_format", DSN{Path: "gorm.db", TimeFormat: "rfc3339"}, false},
+		{"invalid_txlock", DSN{Path: "gorm.db", TxLock: "shared"}, false},
+	}
+
+	for _, p := range params {
+		t.Run(p.name, func(t *testing.T) {
+			if err := p.dsn.Validate(); (err == nil) != p.valid {
+				t.Errorf("Validate() = %v, expect valid: %v", err, p.valid)
+			}
+		})
+	}
+}
+
+func TestDSNPragma(t *testing.T) {
+	dsn := DSN{Pragmas: []string{"foreign_keys(1)", "journal_mode = WAL", "FOREIGN_KEYS=0"}}
+
+	value, ok := dsn.Pragma("foreign_keys")
+	tests.AssertEqual(t, ok, true)
+	tests.AssertEqual(t, value, "0")
+
+	value, _ = dsn.Pragma("journal_mode")
+	tests.AssertEqual(t, value, "WAL")
+
+	_, ok = dsn.Pragma("synchronous")
+	tests.AssertEqual(t, ok, false)
+}
@@ -1,18 +1,126 @@
 package sqlite
 
 import (
-	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	msqlite "modernc.org/sqlite"
 )
 
 // The error codes to map sqlite errors to gorm errors, here is a reference about error codes for sqlite https://www.sqlite.org/rescode.html.
-var errCodes = map[int]error{
-	1555: gorm.ErrDuplicatedKey,
-	2067: gorm.ErrDuplicatedKey,
-	787:  gorm.ErrForeignKeyViolated,
+// Extended codes are looked up first, then the primary code.
+var errCodes = map[int][]error{
+	// extended constraint codes
+	1555: {gorm.ErrDuplicatedKey, ErrPrimaryKeyViolated},
+	2067: {gorm.ErrDuplicatedKey, ErrUniqueViolated},
+	787:  {gorm.ErrForeignKeyViolated},
+	1299: {ErrNotNullViolated},
+	275:  {gorm.ErrCheckConstraintViolated, ErrCheckViolated},
+	// primary codes
+	5:  {ErrBusy},
+	6:  {ErrLocked},
+	8:  {ErrReadOnly},
+	9:  {ErrInterrupted},
+	11: {ErrCorrupt},
+	13: {ErrFull},
+	18: {ErrTooBig},
 }
 
+// errNames are the symbolic names of the result codes, extended codes not listed here use the name of their primary code.
+var errNames = map[int]string{
+	1: "SQLITE_ERROR", 2: "SQLITE_INTERNAL", 3: "SQLITE_PERM", 4: "SQLITE_ABORT", 5: "SQLITE_BUSY",
+	6: "SQLITE_LOCKED", 7: "SQLITE_NOMEM", 8: "SQLITE_READONLY", 9: "SQLITE_INTERRUPT", 10: "SQLITE_IOERR",
+	11: "SQLITE_CORRUPT", 12: "SQLITE_NOTFOUND", 13: "SQLITE_FULL", 14: "SQLITE_CANTOPEN", 15: "SQLITE_PROTOCOL",
+	16: "SQLITE_EMPTY", 17: "SQLITE_SCHEMA", 18: "SQLITE_TOOBIG", 19: "SQLITE_CONSTRAINT", 20: "SQLITE_MISMATCH",
+	21: "SQLITE_MISUSE", 22: "SQLITE_NOLFS", 23: "SQLITE_AUTH", 24: "SQLITE_FORMAT", 25: "SQLITE_RANGE",
+	26: "SQLITE_NOTADB", 27: "SQLITE_NOTICE", 28: "SQLITE_WARNING",
+	261: "SQLITE_BUSY_RECOVERY", 517: "SQLITE_BUSY_SNAPSHOT", 773: "SQLITE_BUSY_TIMEOUT",
+	262: "SQLITE_LOCKED_SHAREDCACHE", 518: "SQLITE_LOCKED_VTAB",
+	264: "SQLITE_READONLY_RECOVERY", 520: "SQLITE_READONLY_CANTLOCK", 776: "SQLITE_READONLY_ROLLBACK",
+	1032: "SQLITE_READONLY_DBMOVED", 1288: "SQLITE_READONLY_CANTINIT", 1544: "SQLITE_READONLY_DIRECTORY",
+	267: "SQLITE_CORRUPT_VTAB", 523: "SQLITE_CORRUPT_SEQUENCE", 779: "SQLITE_CORRUPT_INDEX",
+	275: "SQLITE_CONSTRAINT_CHECK", 531: "SQLITE_CONSTRAINT_COMMITHOOK", 787: "SQLITE_CONSTRAINT_FOREIGNKEY",
+	1043: "SQLITE_CONSTRAINT_FUNCTION", 1299: "SQLITE_CONSTRAINT_NOTNULL", 1555: "SQLITE_CONSTRAINT_PRIMARYKEY",
+	1811: "SQLITE_CONSTRAINT_TRIGGER", 2067: "SQLITE_CONSTRAINT_UNIQUE", 2323: "SQLITE_CONSTRAINT_VTAB",
+	2579: "SQLITE_CONSTRAINT_ROWID", 2835: "SQLITE_CONSTRAINT_PINNED", 3091: "SQLITE_CONSTRAINT_DATATYPE",
+}
+
+// ConstraintKind is the kind of constraint violated by a statement.
+type ConstraintKind string
+
+const (
+	ConstraintUnique     ConstraintKind = "unique"
+	ConstraintPrimaryKey ConstraintKind = "primary key"
+	ConstraintNotNull    ConstraintKind = "not null"
+	ConstraintCheck      ConstraintKind = "check"
+	ConstraintForeignKey ConstraintKind = "foreign key"
+)
+
+var constraintKinds = map[int]ConstraintKind{
+	2067: ConstraintUnique,
+	1555: ConstraintPrimaryKey,
+	1299: ConstraintNotNull,
+	275:  ConstraintCheck,
+	787:  ConstraintForeignKey,
+}
+
+// Error is a SQLite error returned by Translate, errors.Is matches it with the gorm error of its code,
+// e.g. gorm.ErrDuplicatedKey, and errors.As with the *sqlite.Error of modernc.org/sqlite.
+type Error struct {
+	// Code is the primary result code, e.g. 19 for SQLITE_CONSTRAINT.
+	Code int
+	// ExtendedCode is the extended result code, e.g. 2067 for SQLITE_CONSTRAINT_UNIQUE.
+	ExtendedCode int
+	// Name is the symbolic name of ExtendedCode, e.g. SQLITE_CONSTRAINT_UNIQUE.
+	Name string
+	// Message is the message of SQLite, e.g. "UNIQUE constraint failed: users.email".
+	Message string
+	// Table and Columns are parsed from the message of constraint violations, when SQLite reports them.
+	Table   string
+	Columns []string
+	// Constraint is the kind of the violated constraint, empty for other errors.
+	Constraint ConstraintKind
+	// ConstraintName is the name of a violated CHECK constraint, or its expression when it has no name,
+	// or the name of a unique index on expressions.
+	ConstraintName string
+	// Fields are the names of the schema fields of Columns or ConstraintName, they are only known for
+	// errors of create, update and delete statements of a model, with TranslateError enabled.
+	Fields []string
+	// ForeignKeyViolations are the rows the statement would have left without parent, they are only
+	// collected with ForeignKeyDiagnostics and TranslateError enabled.
+	ForeignKeyViolations []ForeignKeyViolation
+
+	err error
+	// targets are the errors matched by Is, from the ErrorCodes of the Dialector and errCodes
+	targets []error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the driver.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is one of the errors translated from the code of e.
+func (e *Error) Is(target error) bool {
+	for _, translated := range e.targets {
+		if translated == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrMessage was used to decode the codes of driver errors.
+//
+// Deprecated: use errors.As with *Error on the errors returned by Translate.
 type ErrMessage struct {
 	Code         int `json:"Code"`
 	ExtendedCode int `json:"ExtendedCode"`
@@ -22,19 +130,149 @@ type ErrMessage struct {
 // Translate it will translate the error to native gorm errors.
 // We are not using go-sqlite3 error type intentionally here because it will need the CGO_ENABLED=1 and cross-C-compiler.
 func (dialector Dialector) Translate(err error) error {
-	parsedErr, marshalErr := json.Marshal(err)
-	if marshalErr != nil {
+	var translated *Error
+	if errors.As(err, &translated) {
 		return err
 	}
 
-	var errMsg ErrMessage
-	unmarshalErr := json.Unmarshal(parsedErr, &errMsg)
-	if unmarshalErr != nil {
+	var sqliteErr *msqlite.Error
+	if !errors.As(err, &sqliteErr) {
 		return err
 	}
 
-	if translatedErr, found := errCodes[errMsg.ExtendedCode]; found {
-		return translatedErr
+	e := newError(sqliteErr, dialector.ErrorCodes)
+	if dialector.ErrorTranslator != nil {
+		if translated := dialector.ErrorTranslator(e); translated != nil {
+			return translated
+		}
+	}
+	return e
+}
+
+// newError translates err, the errors of codes are matched in addition to those of errCodes.
+func newError(err *msqlite.Error, codes map[int]error) *Error {
+	e := &Error{
+		Code:         err.Code() & 0xff,
+		ExtendedCode: err.Code(),
+		Message:      errorMessage(err.Error()),
+		Constraint:   constraintKinds[err.Code()],
+		err:          err,
+	}
+
+	for _, code := range []int{e.ExtendedCode, e.Code} {
+		if custom, ok := codes[code]; ok {
+			e.targets = append(e.targets, custom)
+		}
+		e.targets = append(e.targets, errCodes[code]...)
+		if e.Code == e.ExtendedCode {
+			break
+		}
+	}
+
+	if e.Name = errNames[e.ExtendedCode]; e.Name == "" {
+		e.Name = errNames[e.Code]
+	}
+
+	switch e.Constraint {
+	case ConstraintUnique, ConstraintPrimaryKey, ConstraintNotNull:
+		// unique indexes on expressions are reported as "UNIQUE constraint failed: index 'idx_name'"
+		if matches := constraintIndexRegexp.FindStringSubmatch(e.Message); len(matches) > 1 {
+			e.ConstraintName = matches[1]
+		} else {
+			e.Table, e.Columns = parseConstraintColumns(e.Message)
+		}
+	case ConstraintCheck:
+		e.ConstraintName = strings.TrimPrefix(e.Message, "CHECK constraint failed: ")
+	}
+	return e
+}
+
+// errorMessage strips the description of the code and the code itself from the message of the driver,
+// "constraint failed: UNIQUE constraint failed: users.email (2067)" is reported as
+// "UNIQUE constraint failed: users.email".
+func errorMessage(msg string) string {
+	msg = strings.TrimSuffix(msg, " (SQLITE_BUSY)")
+	if pos := strings.LastIndex(msg, " ("); pos >= 0 && strings.HasSuffix(msg, ")") {
+		if _, err := strconv.Atoi(msg[pos+2 : len(msg)-1]); err == nil {
+			msg = msg[:pos]
+		}
+	}
+	// the description of the code never contains a colon
+	if _, detail, ok := strings.Cut(msg, ": "); ok {
+		return detail
+	}
+	return msg
+}
+
+// registerErrorFields maps the columns and check constraints of translated errors to the fields of the
+// statement's schema.
+func registerErrorFields(db *gorm.DB) error {
+	errorFields := func(db *gorm.DB) {
+		var sqliteErr *Error
+		if db.Error == nil || db.Statement.Schema == nil || !errors.As(db.Error, &sqliteErr) {
+			return
+		}
+		sqliteErr.Fields = schemaFields(db.Statement.Schema, sqliteErr)
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("sqlite:error_fields", errorFields); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("sqlite:error_fields", errorFields); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("sqlite:error_fields", errorFields)
+}
+
+func schemaFields(s *schema.Schema, e *Error) (fields []string) {
+	if e.Constraint == ConstraintCheck {
+		for _, check := range s.ParseCheckConstraints() {
+			if check.Field != nil && (check.Name == e.ConstraintName || check.Constraint == e.ConstraintName) {
+				return []string{check.Field.Name}
+			}
+		}
+		return nil
+	}
+
+	if e.ConstraintName != "" {
+		if index := s.LookIndex(e.ConstraintName); index != nil {
+			for _, option := range index.Fields {
+				if option.Field != nil {
+					fields = append(fields, option.Field.Name)
+				}
+			}
+		}
+		return fields
+	}
+
+	if _, table := splitTable(s.Table); e.Table != table {
+		return nil
+	}
+	for _, column := range e.Columns {
+		if field := s.LookUpField(column); field != nil {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+var constraintIndexRegexp = regexp.MustCompile(`^UNIQUE constraint failed: index '(.+)'$`)
+
+var constraintColumnsRegexp = regexp.MustCompile(`^(?:UNIQUE|NOT NULL) constraint failed: (.+)$`)
+
+// parseConstraintColumns parses "UNIQUE constraint failed: users.email, users.tenant_id".
+func parseConstraintColumns(msg string) (table string, columns []string) {
+	matches := constraintColumnsRegexp.FindStringSubmatch(msg)
+	if len(matches) < 2 {
+		return "", nil
+	}
+
+	for _, column := range strings.Split(matches[1], ", ") {
+		// the table may be qualified with the alias of an attached database
+		if pos := strings.LastIndexByte(column, '.'); pos >= 0 {
+			table, column = column[:pos], column[pos+1:]
+		}
+		columns = append(columns, column)
 	}
-	return err
+	return table, columns
 }
@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+	msqlite "modernc.org/sqlite"
+)
+
+type TranslatedParent struct {
+	ID uint
+}
+
+type TranslatedUser struct {
+	ID       uint
+	Email    string `gorm:"uniqueIndex:idx_email_tenant;not null"`
+	TenantID uint   `gorm:"uniqueIndex:idx_email_tenant"`
+	ParentID *uint
+	Parent   *TranslatedParent
+}
+
+type TranslatedProduct struct {
+	ID     uint
+	Code   string `gorm:"uniqueIndex:idx_lower_code,expression:lower(code)"`
+	Amount int    `gorm:"check:chk_amount,amount > 0"`
+}
+
+func TestTranslate(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:     "file:translatedatabase?mode=memory&cache=shared",
+		Pragmas: &Pragmas{ForeignKeys: &[]bool{true}[0]},
+	}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&TranslatedParent{}, &TranslatedUser{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	db.Create(&TranslatedUser{Email: "a@example.com", TenantID: 1})
+
+	err = db.Create(&TranslatedUser{Email: "a@example.com", TenantID: 1}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("Expected ErrDuplicatedKey, got %v", err)
+	}
+	var sqliteErr *Error
+	if !errors.As(err, &sqliteErr) {
+		t.Fatalf("Expected a *sqlite.Error, got %T", err)
+	}
+	tests.AssertEqual(t, sqliteErr.Code, 19)
+	tests.AssertEqual(t, sqliteErr.ExtendedCode, 2067)
+	tests.AssertEqual(t, sqliteErr.Name, "SQLITE_CONSTRAINT_UNIQUE")
+	tests.AssertEqual(t, sqliteErr.Message, "UNIQUE constraint failed: translated_users.email, translated_users.tenant_id")
+	tests.AssertEqual(t, sqliteErr.Table, "translated_users")
+	tests.AssertEqual(t, sqliteErr.Columns, []string{"email", "tenant_id"})
+	tests.AssertEqual(t, sqliteErr.Constraint, ConstraintUnique)
+	tests.AssertEqual(t, sqliteErr.Fields, []string{"Email", "TenantID"})
+	if !errors.Is(err, ErrUniqueViolated) || errors.Is(err, ErrPrimaryKeyViolated) {
+		t.Errorf("Expected a unique violation only, got %v", err)
+	}
+
+	var driverErr *msqlite.Error
+	if !errors.As(err, &driverErr) || driverErr.Code() != 2067 {
+		t.Errorf("Expected the driver error to be unwrapped, got %v", driverErr)
+	}
+	if db.Dialector.(*Dialector).Translate(err) != err {
+		t.Errorf("Expected a translated error to be returned as is")
+	}
+
+	parentID := uint(42)
+	err = db.Create(&TranslatedUser{Email: "b@example.com", ParentID: &parentID}).Error
+	if !errors.Is(err, gorm.ErrForeignKeyViolated) || !errors.As(err, &sqliteErr) || sqliteErr.Constraint != ConstraintForeignKey {
+		t.Errorf("Expected ErrForeignKeyViolated, got %v", err)
+	}
+
+	err = db.Exec("INSERT INTO translated_users (email, tenant_id) VALUES (NULL, 2)").Error
+	if !errors.As(err, &sqliteErr) {
+		t.Fatalf("Expected a *sqlite.Error, got %T", err)
+	}
+	tests.AssertEqual(t, sqliteErr.Constraint, ConstraintNotNull)
+	tests.AssertEqual(t, sqliteErr.Table, "translated_users")
+	tests.AssertEqual(t, sqliteErr.Columns, []string{"email"})
+	if !errors.Is(err, ErrNotNullViolated) || errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("Expected a not null violation only, got %v", err)
+	}
+
+	err = db.Exec("INSERT INTO translated_users (id, email) VALUES (1, 'c@example.com')").Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) || !errors.Is(err, ErrPrimaryKeyViolated) {
+		t.Errorf("Expected a primary key violation, got %v", err)
+	}
+
+	db.Exec("CREATE TABLE translated_checks (amount integer CONSTRAINT chk_amount CHECK (amount > 0))")
+	err = db.Exec("INSERT INTO translated_checks VALUES (-1)").Error
+	if !errors.Is(err, gorm.ErrCheckConstraintViolated) || !errors.Is(err, ErrCheckViolated) {
+		t.Errorf("Expected a check violation, got %v", err)
+	}
+
+	readOnly, err := gorm.Open(New(Config{DSN: "file:translatedatabase?mode=memory&cache=shared", ReadOnly: true}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(readOnly)
+	if err := readOnly.Exec("DELETE FROM translated_checks").Error; !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected SQLITE_READONLY to match ErrReadOnly, got %v", err)
+	}
+
+	err = db.Exec("SELECT * FROM missing_table").Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Name != "SQLITE_ERROR" || sqliteErr.Message != "no such table: missing_table" {
+		t.Errorf("Expected a generic error, got %+v", sqliteErr)
+	}
+}
+
+func TestTranslateFields(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("translatefields"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&TranslatedProduct{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+
+	var sqliteErr *Error
+	err = db.Create(&TranslatedProduct{Code: "a", Amount: -1}).Error
+	if !errors.As(err, &sqliteErr) {
+		t.Fatalf("Expected a *sqlite.Error, got %v", err)
+	}
+	tests.AssertEqual(t, sqliteErr.Constraint, ConstraintCheck)
+	tests.AssertEqual(t, sqliteErr.ConstraintName, "chk_amount")
+	tests.AssertEqual(t, sqliteErr.Fields, []string{"Amount"})
+
+	db.Create(&TranslatedProduct{Code: "a", Amount: 1})
+	err = db.Create(&TranslatedProduct{Code: "A", Amount: 1}).Error
+	if !errors.As(err, &sqliteErr) {
+		t.Fatalf("Expected a *sqlite.Error, got %v", err)
+	}
+	tests.AssertEqual(t, sqliteErr.ConstraintName, "idx_lower_code")
+	tests.AssertEqual(t, sqliteErr.Fields, []string{"Code"})
+
+	var product TranslatedProduct
+	db.First(&product)
+	err = db.Model(&product).Update("amount", 0).Error
+	if !errors.As(err, &sqliteErr) {
+		t.Fatalf("Expected a *sqlite.Error, got %v", err)
+	}
+	tests.AssertEqual(t, sqliteErr.Fields, []string{"Amount"})
+}
+
+func TestTranslateOverrides(t *testing.T) {
+	var (
+		errTriggerAborted    = errors.New("trigger aborted")
+		errInsufficientFunds = errors.New("insufficient funds")
+	)
+
+	open := func(config Config) *gorm.DB {
+		config.DSN = "file:translateoverrides?mode=memory&cache=shared"
+		db, err := gorm.Open(New(config), &gorm.Config{TranslateError: true})
+		if err != nil {
+			t.Fatalf("Expected Open to succeed; got error: %v", err)
+		}
+		return db
+	}
+
+	db := open(Config{})
+	defer Close(db)
+	db.Exec("CREATE TABLE accounts (balance integer)")
+	db.Exec("CREATE TRIGGER accounts_balance BEFORE INSERT ON accounts WHEN NEW.balance < 0 BEGIN SELECT RAISE(ABORT, 'insufficient_funds'); END")
+
+	custom := open(Config{
+		ErrorCodes: map[int]error{1811: errTriggerAborted},
+		ErrorTranslator: func(err *Error) error {
+			if err.Message == "insufficient_funds" {
+				return fmt.Errorf("%w: %v", errInsufficientFunds, err.Message)
+			}
+			return nil
+		},
+	})
+	defer Close(custom)
+
+	err := custom.Exec("INSERT INTO accounts VALUES (-1)").Error
+	if !errors.Is(err, errInsufficientFunds) {
+		t.Errorf("Expected the ErrorTranslator result, got %v", err)
+	}
+
+	custom.Exec("CREATE TEMP TRIGGER accounts_other BEFORE INSERT ON accounts WHEN NEW.balance = 0 BEGIN SELECT RAISE(ABORT, 'other'); END")
+	err = custom.Exec("INSERT INTO accounts VALUES (0)").Error
+	var sqliteErr *Error
+	if !errors.Is(err, errTriggerAborted) || !errors.As(err, &sqliteErr) || sqliteErr.Name != "SQLITE_CONSTRAINT_TRIGGER" {
+		t.Errorf("Expected the ErrorCodes of the Dialector to match, got %v", err)
+	}
+
+	// the other Dialector of the process keeps the built-in translation
+	err = db.Exec("INSERT INTO accounts VALUES (-1)").Error
+	if errors.Is(err, errTriggerAborted) || errors.Is(err, errInsufficientFunds) || !errors.As(err, &sqliteErr) {
+		t.Errorf("Expected the built-in translation, got %v", err)
+	}
+}
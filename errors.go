@@ -3,5 +3,25 @@ package sqlite
 import "errors"
 
 var (
+	// ErrReadOnly is returned for writes and migrations on a Dialector opened with ReadOnly or Immutable,
+	// errors translated from SQLITE_READONLY match it too.
+	ErrReadOnly = errors.New("sqlite: database is read-only")
+	// ErrForeignKeysInTransaction is returned by migrations recreating a table within a transaction while the
+	// foreign keys are enforced, SQLite can't turn them off there and dropping the table would delete its children.
+	ErrForeignKeysInTransaction  = errors.New("sqlite: foreign keys can't be turned off within a transaction")
 	ErrConstraintsNotImplemented = errors.New("constraints not implemented on sqlite, consider using DisableForeignKeyConstraintWhenMigrating, more details https://github.com/go-gorm/gorm/wiki/GORM-V2-Release-Note-Draft#all-new-migrator")
 )
+
+// Errors matched by the errors returned by Translate, see errCodes.
+var (
+	ErrUniqueViolated     = errors.New("sqlite: unique constraint violated")
+	ErrPrimaryKeyViolated = errors.New("sqlite: primary key constraint violated")
+	ErrNotNullViolated    = errors.New("sqlite: not null constraint violated")
+	ErrCheckViolated      = errors.New("sqlite: check constraint violated")
+	ErrBusy               = errors.New("sqlite: database is busy")
+	ErrLocked             = errors.New("sqlite: table is locked")
+	ErrFull               = errors.New("sqlite: database or disk is full")
+	ErrCorrupt            = errors.New("sqlite: database disk image is malformed")
+	ErrTooBig             = errors.New("sqlite: string or blob too big")
+	ErrInterrupted        = errors.New("sqlite: statement interrupted")
+)
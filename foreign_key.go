@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ForeignKeyViolation is a row reported by PRAGMA foreign_key_check.
+type ForeignKeyViolation struct {
+	// Table is the child table holding the row without parent.
+	Table string
+	// RowID is the rowid of the row, zero for WITHOUT ROWID tables.
+	RowID int64
+	// Parent is the table referenced by the violated foreign key.
+	Parent string
+	// FKID is the id of the foreign key in PRAGMA foreign_key_list(Table).
+	FKID int
+}
+
+// ForeignKeyCheckError is returned by the migrator when recreating a table leaves rows without parent,
+// the recreation is rolled back.
+type ForeignKeyCheckError struct {
+	Table      string
+	Violations []ForeignKeyViolation
+}
+
+func (e *ForeignKeyCheckError) Error() string {
+	return fmt.Sprintf("sqlite: recreating table %v violates %d foreign key constraints", e.Table, len(e.Violations))
+}
+
+// Unwrap makes the error match gorm.ErrForeignKeyViolated.
+func (e *ForeignKeyCheckError) Unwrap() error {
+	return gorm.ErrForeignKeyViolated
+}
+
+// registerForeignKeyDiagnostics explains the foreign key violations of create, update, delete and raw
+// statements. SQLite doesn't report which key failed, so the statement is run again with the checks
+// deferred to find the orphaned rows with PRAGMA foreign_key_check, then rolled back.
+func registerForeignKeyDiagnostics(db *gorm.DB) error {
+	diagnose := func(db *gorm.DB) {
+		var sqliteErr *Error
+		if db.Error == nil || !errors.As(db.Error, &sqliteErr) || sqliteErr.Constraint != ConstraintForeignKey || db.Statement.SQL.Len() == 0 {
+			return
+		}
+
+		violations, err := foreignKeyViolations(db)
+		if err != nil {
+			db.Logger.Warn(db.Statement.Context, "sqlite: foreign key diagnostics: %v", err)
+			return
+		}
+		sqliteErr.ForeignKeyViolations = violations
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("sqlite:foreign_key_diagnostics", diagnose); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("sqlite:foreign_key_diagnostics", diagnose); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("sqlite:foreign_key_diagnostics", diagnose); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:raw").Register("sqlite:foreign_key_diagnostics", diagnose)
+}
+
+// foreignKeyViolations runs the statement of db again within a savepoint on the same transaction or pinned
+// connection, or on a dedicated connection within a transaction, and rolls it back once the violations are
+// collected. Outside of a transaction the savepoint starts one.
+func foreignKeyViolations(db *gorm.DB) (violations []ForeignKeyViolation, err error) {
+	ctx := db.Statement.Context
+	pool := db.Statement.ConnPool
+
+	// a check of the whole database would report the violations left behind by other statements too
+	table := tableOf(db.Statement)
+	if table == "" {
+		if table = writtenTable(db.Statement.SQL.String()); table == "" {
+			return nil, nil
+		}
+	}
+
+	switch pool.(type) {
+	case gorm.TxCommitter, *sql.Conn:
+		var deferred int
+		if err := pool.QueryRowContext(ctx, "PRAGMA defer_foreign_keys").Scan(&deferred); err != nil {
+			return nil, err
+		}
+		if _, err := pool.ExecContext(ctx, "SAVEPOINT sqlite_fk_diagnostics"); err != nil {
+			return nil, err
+		}
+		defer func() {
+			// the pragma isn't rolled back, it's only reset at the end of the transaction
+			pool.ExecContext(ctx, "ROLLBACK TO sqlite_fk_diagnostics")
+			pool.ExecContext(ctx, "RELEASE sqlite_fk_diagnostics")
+			pool.ExecContext(ctx, "PRAGMA defer_foreign_keys = "+strconv.Itoa(deferred))
+		}()
+	default:
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+			return nil, err
+		}
+		defer conn.ExecContext(ctx, "ROLLBACK")
+		pool = conn
+	}
+
+	if _, err := pool.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return nil, err
+	}
+	if _, err := pool.ExecContext(ctx, db.Statement.SQL.String(), db.Statement.Vars...); err != nil {
+		return nil, err
+	}
+
+	schema, table := splitTable(table)
+	if schema == "" {
+		schema = "main"
+	}
+	return checkForeignKeys(ctx, pool, schema, table)
+}
+
+var writtenTableRegexp = regexp.MustCompile(fmt.Sprintf(
+	`(?is)^\s*(?:INSERT(?:\s+OR\s+\w+)?\s+INTO|REPLACE\s+INTO|UPDATE(?:\s+OR\s+\w+)?|DELETE\s+FROM)\s+((?:%[1]s\.)?%[1]s)`,
+	"[`\"[]?[\\w-]+[`\"\\]]?",
+))
+
+// writtenTable returns the table written by a raw INSERT, REPLACE, UPDATE or DELETE statement, qualified
+// with its schema if any, or an empty string.
+func writtenTable(sql string) string {
+	matches := writtenTableRegexp.FindStringSubmatch(sql)
+	if matches == nil {
+		return ""
+	}
+	return strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(matches[1])
+}
+
+// checkForeignKeys returns the violations of the rows of table in schema, and of the rows of its children
+// for updates and deletes of parents.
+func checkForeignKeys(ctx context.Context, pool gorm.ConnPool, schema, table string) (violations []ForeignKeyViolation, err error) {
+	tables := []string{table}
+	rows, err := pool.QueryContext(ctx,
+		`SELECT DISTINCT m.name FROM pragma_table_list m JOIN pragma_foreign_key_list(m.name, m.schema) f
+		WHERE m.schema = ? AND m.type = 'table' AND f."table" = ? COLLATE NOCASE AND m.name <> ?`, schema, table, table)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var child string
+		if err := rows.Scan(&child); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, child)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		if violations, err = appendForeignKeyViolations(ctx, pool, violations, "SELECT * FROM pragma_foreign_key_check(?, ?)", table, schema); err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
+
+func appendForeignKeyViolations(ctx context.Context, pool gorm.ConnPool, violations []ForeignKeyViolation, query string, args ...interface{}) ([]ForeignKeyViolation, error) {
+	rows, err := pool.QueryContext(ctx, query, args...)
+	if err != nil {
+		return violations, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			violation ForeignKeyViolation
+			rowID     sql.NullInt64
+		)
+		if err := rows.Scan(&violation.Table, &rowID, &violation.Parent, &violation.FKID); err != nil {
+			return violations, err
+		}
+		violation.RowID = rowID.Int64
+		violations = append(violations, violation)
+	}
+	return violations, rows.Err()
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type DiagnosedParent struct {
+	ID       uint
+	Children []DiagnosedChild `gorm:"foreignKey:ParentID"`
+}
+
+type DiagnosedChild struct {
+	ID       uint
+	OwnerID  *uint
+	ParentID uint
+	Owner    *DiagnosedParent `gorm:"foreignKey:OwnerID"`
+}
+
+func TestForeignKeyDiagnostics(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:                   "file:foreignkeydiagnostics?mode=memory&cache=shared",
+		Pragmas:               &Pragmas{ForeignKeys: &[]bool{true}[0]},
+		ForeignKeyDiagnostics: true,
+	}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&DiagnosedParent{}, &DiagnosedChild{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	parent := DiagnosedParent{}
+	db.Create(&parent)
+	db.Create(&DiagnosedChild{ParentID: parent.ID})
+
+	var fkID int
+	db.Raw(`SELECT id FROM pragma_foreign_key_list('diagnosed_children') WHERE "from" = 'parent_id'`).Scan(&fkID)
+
+	owner := uint(42)
+	for _, tx := range []*gorm.DB{db, db.Session(&gorm.Session{SkipDefaultTransaction: true})} {
+		err := tx.Create(&DiagnosedChild{ID: 10, ParentID: 7, OwnerID: &owner}).Error
+		var sqliteErr *Error
+		if !errors.As(err, &sqliteErr) || sqliteErr.Constraint != ConstraintForeignKey {
+			t.Fatalf("Expected a foreign key violation, got %v", err)
+		}
+		if len(sqliteErr.ForeignKeyViolations) != 2 {
+			t.Fatalf("Expected both foreign keys to be reported, got %+v", sqliteErr.ForeignKeyViolations)
+		}
+		for _, violation := range sqliteErr.ForeignKeyViolations {
+			tests.AssertEqual(t, violation.Table, "diagnosed_children")
+			tests.AssertEqual(t, violation.RowID, int64(10))
+			tests.AssertEqual(t, violation.Parent, "diagnosed_parents")
+		}
+	}
+
+	// statements run on a pinned connection are diagnosed on it too
+	db.Connection(func(tx *gorm.DB) error {
+		err := tx.Session(&gorm.Session{SkipDefaultTransaction: true}).Create(&DiagnosedChild{ID: 11, ParentID: 7}).Error
+		var sqliteErr *Error
+		if !errors.As(err, &sqliteErr) || len(sqliteErr.ForeignKeyViolations) != 1 {
+			t.Fatalf("Expected the violation to be reported on the pinned connection, got %v", err)
+		}
+		tests.AssertEqual(t, sqliteErr.ForeignKeyViolations[0].RowID, int64(11))
+		return nil
+	})
+
+	var count int64
+	db.Model(&DiagnosedChild{}).Count(&count)
+	tests.AssertEqual(t, count, int64(1))
+
+	db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&parent).Error
+		var sqliteErr *Error
+		if !errors.As(err, &sqliteErr) || len(sqliteErr.ForeignKeyViolations) != 1 {
+			t.Fatalf("Expected the orphaned child to be reported, got %v", err)
+		}
+		tests.AssertEqual(t, sqliteErr.ForeignKeyViolations[0], ForeignKeyViolation{
+			Table: "diagnosed_children", RowID: 1, Parent: "diagnosed_parents", FKID: fkID,
+		})
+
+		var deferred int
+		tx.Raw("PRAGMA defer_foreign_keys").Scan(&deferred)
+		tests.AssertEqual(t, deferred, 0)
+		return nil
+	})
+	if err := db.First(&DiagnosedParent{}, parent.ID).Error; err != nil {
+		t.Errorf("Expected the parent to be kept, got error: %v", err)
+	}
+}
+
+func TestForeignKeyDiagnosticsRaw(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:                   "file:foreignkeydiagnosticsraw?mode=memory&cache=shared",
+		Pragmas:               &Pragmas{ForeignKeys: &[]bool{true}[0]},
+		ForeignKeyDiagnostics: true,
+	}), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&DiagnosedParent{}, &DiagnosedChild{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+
+	// a violation left behind in another table before
+	db.Exec("CREATE TABLE legacy_children (parent_id INTEGER REFERENCES diagnosed_parents(id))")
+	db.Exec("PRAGMA foreign_keys = OFF")
+	db.Exec("INSERT INTO legacy_children VALUES (99)")
+	db.Exec("PRAGMA foreign_keys = ON")
+
+	err = db.Exec("INSERT INTO `diagnosed_children` (id, parent_id) VALUES (?, ?)", 10, 7).Error
+	var sqliteErr *Error
+	if !errors.As(err, &sqliteErr) || len(sqliteErr.ForeignKeyViolations) != 1 {
+		t.Fatalf("Expected the violation of the statement only, got %v", err)
+	}
+	tests.AssertEqual(t, sqliteErr.ForeignKeyViolations[0].Table, "diagnosed_children")
+
+	// statements writing to a table that can't be told aren't diagnosed
+	err = db.Exec("WITH ids AS (SELECT 11 AS id) INSERT INTO diagnosed_children (id, parent_id) SELECT id, 7 FROM ids").Error
+	if !errors.As(err, &sqliteErr) || len(sqliteErr.ForeignKeyViolations) != 0 {
+		t.Errorf("Expected no violations to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	msqlite "modernc.org/sqlite"
+)
+
+type (
+	// Function is a Go implementation of a scalar or aggregate SQL function, see https://sqlite.org/appfunc.html
+	Function = msqlite.FunctionImpl
+	// FunctionContext is passed to the callbacks of a Function.
+	FunctionContext = msqlite.FunctionContext
+	// AggregateFunction is one evaluation of an aggregate or window Function.
+	AggregateFunction = msqlite.AggregateFunction
+)
+
+// modernc.org/sqlite keeps functions and collations in the driver itself, they are added to every connection
+// it opens and can't be registered twice. Each name is registered once with a trampoline calling the
+// implementation given last, so opening an equivalent configuration again replaces it for the whole process.
+var registry = struct {
+	sync.Mutex
+	functions  map[string]*atomic.Pointer[Function]
+	collations map[string]*atomic.Pointer[func(left, right string) int]
+}{
+	functions:  map[string]*atomic.Pointer[Function]{},
+	collations: map[string]*atomic.Pointer[func(left, right string) int]{},
+}
+
+func registerFunctions(functions map[string]*Function) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for name, impl := range functions {
+		if current, ok := registry.functions[name]; ok {
+			// the arguments and the kind of the function are fixed by the registration
+			if registered := current.Load(); registered.NArgs != impl.NArgs || registered.Deterministic != impl.Deterministic ||
+				(registered.Scalar == nil) != (impl.Scalar == nil) || (registered.MakeAggregate == nil) != (impl.MakeAggregate == nil) {
+				return fmt.Errorf("sqlite function %q is already registered with another signature", name)
+			}
+			current.Store(impl)
+			continue
+		}
+
+		current := &atomic.Pointer[Function]{}
+		current.Store(impl)
+		trampoline := &Function{NArgs: impl.NArgs, Deterministic: impl.Deterministic}
+		if impl.Scalar != nil {
+			trampoline.Scalar = func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+				return current.Load().Scalar(ctx, args)
+			}
+		}
+		if impl.MakeAggregate != nil {
+			trampoline.MakeAggregate = func(ctx FunctionContext) (AggregateFunction, error) {
+				return current.Load().MakeAggregate(ctx)
+			}
+		}
+
+		if err := msqlite.RegisterFunction(name, trampoline); err != nil {
+			return err
+		}
+		registry.functions[name] = current
+	}
+	return nil
+}
+
+func registerCollations(collations map[string]func(left, right string) int) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for name, impl := range collations {
+		if current, ok := registry.collations[name]; ok {
+			current.Store(&impl)
+			continue
+		}
+
+		current := &atomic.Pointer[func(left, right string) int]{}
+		current.Store(&impl)
+		if err := msqlite.RegisterCollationUtf8(name, func(left, right string) int {
+			return (*current.Load())(left, right)
+		}); err != nil {
+			return err
+		}
+		registry.collations[name] = current
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type sumLengths struct {
+	total int64
+}
+
+func (s *sumLengths) Step(ctx *FunctionContext, args []driver.Value) error {
+	if str, ok := args[0].(string); ok {
+		s.total += int64(len(str))
+	}
+	return nil
+}
+
+func (s *sumLengths) WindowInverse(ctx *FunctionContext, args []driver.Value) error {
+	if str, ok := args[0].(string); ok {
+		s.total -= int64(len(str))
+	}
+	return nil
+}
+
+func (s *sumLengths) WindowValue(ctx *FunctionContext) (driver.Value, error) {
+	return s.total, nil
+}
+
+func (s *sumLengths) Final(ctx *FunctionContext) {}
+
+func TestFunctions(t *testing.T) {
+	newFunctions := func() map[string]*Function {
+		return map[string]*Function{
+			"test_slugify": {
+				NArgs:         1,
+				Deterministic: true,
+				Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+					str, _ := args[0].(string)
+					return strings.ReplaceAll(strings.ToLower(str), " ", "-"), nil
+				},
+			},
+			"test_sum_lengths": {
+				NArgs: 1,
+				MakeAggregate: func(ctx FunctionContext) (AggregateFunction, error) {
+					return &sumLengths{}, nil
+				},
+			},
+		}
+	}
+
+	// opening an equivalent configuration again replaces the implementations registered before
+	for i := 0; i < 2; i++ {
+		db, err := gorm.Open(New(Config{DSN: "file:functionsdatabase?mode=memory&cache=shared", Functions: newFunctions()}), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Expected Open to succeed; got error: %v", err)
+		}
+
+		var slug string
+		if err := db.Raw("SELECT test_slugify(?)", "Hello World").Scan(&slug).Error; err != nil || slug != "hello-world" {
+			t.Errorf("Expected scalar function to work; got %q, error: %v", slug, err)
+		}
+
+		var total int64
+		if err := db.Raw("SELECT test_sum_lengths(v) FROM (SELECT 'ab' AS v UNION ALL SELECT 'cde')").Scan(&total).Error; err != nil || total != 5 {
+			t.Errorf("Expected aggregate function to work; got %d, error: %v", total, err)
+		}
+
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}
+
+	_, err := gorm.Open(New(Config{DSN: "file:functionsdatabase?mode=memory&cache=shared", Functions: map[string]*Function{
+		"test_slugify": {NArgs: 1, Scalar: newFunctions()["test_slugify"].Scalar},
+	}}), &gorm.Config{})
+	if err == nil {
+		t.Errorf("Expected Open to fail with a conflicting function")
+	}
+}
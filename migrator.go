@@ -3,6 +3,8 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"gorm.io/gorm"
@@ -16,20 +18,78 @@ type Migrator struct {
 }
 
 func (m *Migrator) RunWithoutForeignKey(fc func() error) error {
-	var enabled int
-	m.DB.Raw("PRAGMA foreign_keys").Scan(&enabled)
-	if enabled == 1 {
-		m.DB.Exec("PRAGMA foreign_keys = OFF")
-		defer m.DB.Exec("PRAGMA foreign_keys = ON")
+	return m.withoutForeignKeys(func(bool) error {
+		return fc()
+	})
+}
+
+// withoutForeignKeys turns the foreign key enforcement off while fc runs, enabled reports whether it was on.
+func (m *Migrator) withoutForeignKeys(fc func(enabled bool) error) error {
+	return m.onConnection(func() error {
+		var enabled int
+		m.DB.Raw("PRAGMA foreign_keys").Scan(&enabled)
+		if enabled == 1 {
+			// the pragma is a no-op within a transaction
+			var stillEnabled int
+			m.DB.Exec("PRAGMA foreign_keys = OFF")
+			m.DB.Raw("PRAGMA foreign_keys").Scan(&stillEnabled)
+			if stillEnabled == 1 {
+				return ErrForeignKeysInTransaction
+			}
+			defer m.DB.Exec("PRAGMA foreign_keys = ON")
+		}
+
+		return fc(enabled == 1)
+	})
+}
+
+// onConnection runs fc with m.DB pinned to a dedicated connection, so the connection state set by fc,
+// e.g. pragmas, applies to all of its statements. Transactions are pinned to their connection already.
+func (m *Migrator) onConnection(fc func() error) error {
+	switch m.DB.Statement.ConnPool.(type) {
+	case gorm.TxCommitter, *sql.Conn:
+		return fc()
+	}
+
+	db := m.DB
+	defer func() { m.DB = db }()
+	return db.Connection(func(tx *gorm.DB) error {
+		m.DB = tx.Session(&gorm.Session{})
+		return fc()
+	})
+}
+
+// splitTable splits a table name qualified with the alias of an attached database, schema is empty otherwise.
+func splitTable(table string) (schema, name string) {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return schema, name
+	}
+	return "", table
+}
+
+// tableOf returns the table of stmt, qualified with the alias of its attached database if any.
+// gorm parses a schema qualified table name of a model into TableExpr and keeps only the name in Table.
+func tableOf(stmt *gorm.Statement) string {
+	if stmt.TableExpr != nil && stmt.Schema != nil && strings.HasSuffix(stmt.Schema.Table, "."+stmt.Table) {
+		return stmt.Schema.Table
 	}
+	return stmt.Table
+}
 
-	return fc()
+// sqliteMaster returns the schema table of the database holding table, and the unqualified table name.
+func (m Migrator) sqliteMaster(table string) (master, name string) {
+	schema, name := splitTable(table)
+	if schema == "" {
+		return "sqlite_master", name
+	}
+	return m.DB.Statement.Quote(schema) + ".sqlite_master", name
 }
 
 func (m Migrator) HasTable(value interface{}) bool {
 	var count int
 	m.Migrator.RunWithValue(value, func(stmt *gorm.Statement) error {
-		return m.DB.Raw("SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?", stmt.Table).Row().Scan(&count)
+		master, table := m.sqliteMaster(tableOf(stmt))
+		return m.DB.Raw("SELECT count(*) FROM "+master+" WHERE type='table' AND name=?", table).Row().Scan(&count)
 	})
 	return count > 0
 }
@@ -41,7 +101,7 @@ func (m Migrator) DropTable(values ...interface{}) error {
 
 		for i := len(values) - 1; i >= 0; i-- {
 			if err := m.RunWithValue(values[i], func(stmt *gorm.Statement) error {
-				return tx.Exec("DROP TABLE IF EXISTS ?", clause.Table{Name: stmt.Table}).Error
+				return tx.Exec("DROP TABLE IF EXISTS ?", m.CurrentTable(stmt)).Error
 			}); err != nil {
 				return err
 			}
@@ -51,8 +111,24 @@ func (m Migrator) DropTable(values ...interface{}) error {
 	})
 }
 
+// GetTables returns the tables of the main database, and those of attached databases qualified with their alias.
 func (m Migrator) GetTables() (tableList []string, err error) {
-	return tableList, m.DB.Raw("SELECT name FROM sqlite_master where type=?", "table").Scan(&tableList).Error
+	if err = m.DB.Raw("SELECT name FROM sqlite_master where type=?", "table").Scan(&tableList).Error; err != nil {
+		return tableList, err
+	}
+
+	var schemas []string
+	if err = m.DB.Raw("SELECT name FROM pragma_database_list WHERE name NOT IN ?", []string{"main", "temp"}).Scan(&schemas).Error; err != nil {
+		return tableList, err
+	}
+	for _, schema := range schemas {
+		var tables []string
+		if err = m.DB.Raw("SELECT ? || '.' || name FROM "+m.DB.Statement.Quote(schema)+".sqlite_master where type=?", schema, "table").Scan(&tables).Error; err != nil {
+			return tableList, err
+		}
+		tableList = append(tableList, tables...)
+	}
+	return tableList, nil
 }
 
 func (m Migrator) HasColumn(value interface{}, name string) bool {
@@ -65,10 +141,11 @@ func (m Migrator) HasColumn(value interface{}, name string) bool {
 		}
 
 		if name != "" {
-			m.DB.Raw(
-				"SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND (sql LIKE ? OR sql LIKE ? OR sql LIKE ? OR sql LIKE ? OR sql LIKE ?)",
-				"table", stmt.Table, `%"`+name+`" %`, `%`+name+` %`, "%`"+name+"`%", "%["+name+"]%", "%\t"+name+"\t%",
-			).Row().Scan(&count)
+			schema, table := splitTable(tableOf(stmt))
+			if schema == "" {
+				schema = "main"
+			}
+			m.DB.Raw("SELECT count(*) FROM pragma_table_xinfo(?, ?) WHERE name = ? COLLATE NOCASE", table, schema, name).Row().Scan(&count)
 		}
 		return nil
 	})
@@ -76,35 +153,43 @@ func (m Migrator) HasColumn(value interface{}, name string) bool {
 }
 
 func (m Migrator) AlterColumn(value interface{}, name string) error {
-	return m.RunWithoutForeignKey(func() error {
-		return m.recreateTable(value, nil, func(ddl *ddl, stmt *gorm.Statement) (*ddl, []interface{}, error) {
-			if field := stmt.Schema.LookUpField(name); field != nil {
-				var sqlArgs []interface{}
-				for i, f := range ddl.fields {
-					if matches := columnRegexp.FindStringSubmatch(f); len(matches) > 1 && matches[1] == field.DBName {
-						ddl.fields[i] = fmt.Sprintf("`%v` ?", field.DBName)
-						sqlArgs = []interface{}{m.FullDataTypeOf(field)}
-						// table created by old version might look like `CREATE TABLE ? (? varchar(10) UNIQUE)`.
-						// FullDataTypeOf doesn't contain UNIQUE, so we need to add unique constraint.
-						if strings.Contains(strings.ToUpper(matches[3]), " UNIQUE") {
-							uniName := m.DB.NamingStrategy.UniqueName(stmt.Table, field.DBName)
-							uni, _ := m.GuessConstraintInterfaceAndTable(stmt, uniName)
-							if uni != nil {
-								uniSQL, uniArgs := uni.Build()
-								ddl.addConstraint(uniName, uniSQL)
-								sqlArgs = append(sqlArgs, uniArgs...)
-							}
+	return m.recreateTable(value, nil, func(ddl *ddl, stmt *gorm.Statement) (*ddl, []interface{}, error) {
+		if field := stmt.Schema.LookUpField(name); field != nil {
+			var sqlArgs []interface{}
+			for i, f := range ddl.fields {
+				if matches := columnRegexp.FindStringSubmatch(f); len(matches) > 1 && matches[1] == field.DBName {
+					ddl.fields[i] = fmt.Sprintf("`%v` ?", field.DBName)
+					sqlArgs = []interface{}{m.FullDataTypeOf(field)}
+					// table created by old version might look like `CREATE TABLE ? (? varchar(10) UNIQUE)`.
+					// FullDataTypeOf doesn't contain UNIQUE, so we need to add unique constraint.
+					if strings.Contains(strings.ToUpper(matches[3]), " UNIQUE") {
+						uniName := m.DB.NamingStrategy.UniqueName(stmt.Table, field.DBName)
+						uni, _ := m.GuessConstraintInterfaceAndTable(stmt, uniName)
+						if uni != nil {
+							uniSQL, uniArgs := uni.Build()
+							ddl.addConstraint(uniName, uniSQL)
+							sqlArgs = append(sqlArgs, uniArgs...)
 						}
-						break
 					}
+					break
 				}
-				return ddl, sqlArgs, nil
 			}
-			return nil, nil, fmt.Errorf("failed to alter field with name %v", name)
-		})
+			return ddl, sqlArgs, nil
+		}
+		return nil, nil, fmt.Errorf("failed to alter field with name %v", name)
 	})
 }
 
+// FullDataTypeOf adds the collation of the `collate` tag setting right after the data type.
+func (m Migrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	if collate := field.TagSettings["COLLATE"]; collate != "" {
+		dataType := m.DataTypeOf(field)
+		expr.SQL = dataType + " COLLATE " + collate + strings.TrimPrefix(expr.SQL, dataType)
+	}
+	return expr
+}
+
 // ColumnTypes return columnTypes []gorm.ColumnType and execErr error
 func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 	columnTypes := make([]gorm.ColumnType, 0)
@@ -114,7 +199,8 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 			sqlDDL *ddl
 		)
 
-		if err := m.DB.Raw("SELECT sql FROM sqlite_master WHERE type IN ? AND tbl_name = ? AND sql IS NOT NULL order by type = ? desc", []string{"table", "index"}, stmt.Table, "table").Scan(&sqls).Error; err != nil {
+		master, table := m.sqliteMaster(tableOf(stmt))
+		if err := m.DB.Raw("SELECT sql FROM "+master+" WHERE type IN ? AND tbl_name = ? AND sql IS NOT NULL order by type = ? desc", []string{"table", "index"}, table, "table").Scan(&sqls).Error; err != nil {
 			return err
 		}
 
@@ -122,7 +208,7 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 			return err
 		}
 
-		rows, err := m.DB.Session(&gorm.Session{}).Table(stmt.Table).Limit(1).Rows()
+		rows, err := m.DB.Session(&gorm.Session{}).Table(tableOf(stmt)).Limit(1).Rows()
 		if err != nil {
 			return err
 		}
@@ -154,7 +240,29 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 	return columnTypes, execErr
 }
 
+// DropColumn uses ALTER TABLE DROP COLUMN when supported and the column qualifies, and recreates the table otherwise.
+// The indexes using the column are dropped with it. SQLite refuses to drop a column used by a view, trigger or
+// check, its error is returned as the recreated table would leave those broken too.
 func (m Migrator) DropColumn(value interface{}, name string) error {
+	if m.capabilities().DropColumn {
+		var native bool
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			column := name
+			if stmt.Schema != nil {
+				if field := stmt.Schema.LookUpField(name); field != nil {
+					column = field.DBName
+				}
+			}
+
+			if native = m.canDropColumn(tableOf(stmt), column); !native {
+				return nil
+			}
+			return m.DB.Exec("ALTER TABLE ? DROP COLUMN ?", m.CurrentTable(stmt), clause.Column{Name: column}).Error
+		}); err != nil || native {
+			return err
+		}
+	}
+
 	return m.recreateTable(value, nil, func(ddl *ddl, stmt *gorm.Statement) (*ddl, []interface{}, error) {
 		if field := stmt.Schema.LookUpField(name); field != nil {
 			name = field.DBName
@@ -165,9 +273,79 @@ func (m Migrator) DropColumn(value interface{}, name string) error {
 	})
 }
 
+// canDropColumn reports whether ALTER TABLE DROP COLUMN may drop column, which mustn't be part of the primary
+// key, of an index or unique constraint, or of a foreign key. The indexes using it are dropped with it when the
+// table is recreated, which SQLite's DROP COLUMN refuses to do.
+func (m Migrator) canDropColumn(table, column string) bool {
+	master, _ := m.sqliteMaster(table)
+	schema, table := splitTable(table)
+	if schema == "" {
+		schema = "main"
+	}
+
+	var count int
+	if err := m.DB.Raw(
+		`SELECT (SELECT count(*) FROM pragma_table_xinfo(?, ?) WHERE name = ? COLLATE NOCASE AND pk > 0)
+		+ (SELECT count(*) FROM pragma_index_list(?, ?) il JOIN pragma_index_info(il.name, ?) ii WHERE ii.name = ? COLLATE NOCASE)
+		+ (SELECT count(*) FROM pragma_foreign_key_list(?, ?) WHERE "from" = ? COLLATE NOCASE)`,
+		table, schema, column, table, schema, schema, column, table, schema, column,
+	).Row().Scan(&count); err != nil || count > 0 {
+		return false
+	}
+
+	// columns used by expressions or WHERE clauses of indexes aren't listed by pragma_index_info
+	var indexes []schemaObject
+	if err := m.DB.Raw("SELECT type, name, sql FROM "+master+" WHERE type = ? AND tbl_name = ? AND sql IS NOT NULL", "index", table).Scan(&indexes).Error; err != nil {
+		return false
+	}
+	for _, index := range indexes {
+		if index.usedColumn([]string{column}) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// RenameColumn uses ALTER TABLE RENAME COLUMN when supported, and recreates the table otherwise.
+func (m Migrator) RenameColumn(value interface{}, oldName, newName string) error {
+	if m.capabilities().RenameColumn {
+		return m.Migrator.RenameColumn(value, oldName, newName)
+	}
+
+	return m.recreateTable(value, nil, func(ddl *ddl, stmt *gorm.Statement) (*ddl, []interface{}, error) {
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(oldName); field != nil {
+				oldName = field.DBName
+			}
+			if field := stmt.Schema.LookUpField(newName); field != nil {
+				newName = field.DBName
+			}
+		}
+
+		if !ddl.renameColumn(oldName, newName) {
+			return nil, nil, fmt.Errorf("failed to rename column with name %v", oldName)
+		}
+		return ddl, nil, nil
+	})
+}
+
+// capabilities returns the features detected by the Dialector of the migrator.
+func (m Migrator) capabilities() Capabilities {
+	switch dialector := m.Dialector.(type) {
+	case *Dialector:
+		return dialector.capabilities
+	case Dialector:
+		return dialector.capabilities
+	}
+	return Capabilities{}
+}
+
 func (m Migrator) CreateConstraint(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		constraint, table := m.GuessConstraintInterfaceAndTable(stmt, name)
+		if table == stmt.Table {
+			table = tableOf(stmt)
+		}
 
 		return m.recreateTable(value, &table,
 			func(ddl *ddl, stmt *gorm.Statement) (*ddl, []interface{}, error) {
@@ -193,6 +371,9 @@ func (m Migrator) CreateConstraint(value interface{}, name string) error {
 func (m Migrator) DropConstraint(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		constraint, table := m.GuessConstraintInterfaceAndTable(stmt, name)
+		if table == stmt.Table {
+			table = tableOf(stmt)
+		}
 		if constraint != nil {
 			name = constraint.GetName()
 		}
@@ -205,22 +386,95 @@ func (m Migrator) DropConstraint(value interface{}, name string) error {
 	})
 }
 
+// HasConstraint looks up foreign keys and unique constraints of the model in the catalog, and other
+// constraints by their name in the DDL of the table.
 func (m Migrator) HasConstraint(value interface{}, name string) bool {
-	var count int64
+	var found bool
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		constraint, table := m.GuessConstraintInterfaceAndTable(stmt, name)
+		if table == stmt.Table {
+			table = tableOf(stmt)
+		}
 		if constraint != nil {
 			name = constraint.GetName()
 		}
 
-		m.DB.Raw(
-			"SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND (sql LIKE ? OR sql LIKE ? OR sql LIKE ? OR sql LIKE ? OR sql LIKE ?)",
-			"table", table, `%CONSTRAINT "`+name+`" %`, `%CONSTRAINT `+name+` %`, "%CONSTRAINT `"+name+"`%", "%CONSTRAINT ["+name+"]%", "%CONSTRAINT \t"+name+"\t%",
-		).Row().Scan(&count)
-
+		// SQLite doesn't keep the names of foreign keys and unique constraints, they are matched by their columns
+		switch constraint := constraint.(type) {
+		case *schema.Constraint:
+			found = m.hasForeignKey(table, constraint)
+		case *schema.UniqueConstraint:
+			found = m.hasUniqueConstraint(table, constraint.Field.DBName)
+		default:
+			rawDDL, err := m.getRawDDL(table)
+			if err != nil {
+				return err
+			}
+			ddl, err := parseDDL(rawDDL)
+			if err != nil {
+				return err
+			}
+			found = ddl.hasConstraint(name)
+		}
 		return nil
 	})
 
+	return found
+}
+
+// hasForeignKey reports whether table has a foreign key on the columns of constraint, referencing the same columns.
+func (m Migrator) hasForeignKey(table string, constraint *schema.Constraint) bool {
+	schema, table := splitTable(table)
+	if schema == "" {
+		schema = "main"
+	}
+	_, parent := splitTable(constraint.ReferenceSchema.Table)
+
+	var keys []struct {
+		ID    int
+		Table string
+		From  string
+		To    sql.NullString
+	}
+	if err := m.DB.Raw(`SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, table, schema).Scan(&keys).Error; err != nil {
+		return false
+	}
+
+	for start := 0; start < len(keys); {
+		end := start
+		for end < len(keys) && keys[end].ID == keys[start].ID {
+			end++
+		}
+
+		matched := strings.EqualFold(keys[start].Table, parent) && end-start == len(constraint.ForeignKeys)
+		for i := start; matched && i < end; i++ {
+			field, reference := constraint.ForeignKeys[i-start], constraint.References[i-start]
+			// a foreign key without columns references the primary key of the parent
+			matched = strings.EqualFold(keys[i].From, field.DBName) &&
+				(!keys[i].To.Valid && reference.PrimaryKey || strings.EqualFold(keys[i].To.String, reference.DBName))
+		}
+		if matched {
+			return true
+		}
+		start = end
+	}
+	return false
+}
+
+// hasUniqueConstraint reports whether table has a UNIQUE constraint on column alone.
+func (m Migrator) hasUniqueConstraint(table, column string) bool {
+	schema, table := splitTable(table)
+	if schema == "" {
+		schema = "main"
+	}
+
+	var count int
+	m.DB.Raw(
+		`SELECT count(*) FROM pragma_index_list(?, ?) il WHERE il.origin = 'u'
+		AND (SELECT count(*) FROM pragma_index_info(il.name, ?)) = 1
+		AND (SELECT name FROM pragma_index_info(il.name, ?)) = ? COLLATE NOCASE`,
+		table, schema, schema, schema, column,
+	).Row().Scan(&count)
 	return count > 0
 }
 
@@ -254,7 +508,9 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 		if stmt.Schema != nil {
 			if idx := stmt.Schema.LookIndex(name); idx != nil {
 				opts := m.BuildIndexOptions(idx.Fields, stmt)
-				values := []interface{}{clause.Column{Name: idx.Name}, clause.Table{Name: stmt.Table}, opts}
+				// the index is created in the schema of the table, which must not be qualified in ON
+				schema, table := splitTable(tableOf(stmt))
+				values := []interface{}{clause.Column{Table: schema, Name: idx.Name}, clause.Table{Name: table}, opts}
 
 				createIndexSQL := "CREATE "
 				if idx.Class != "" {
@@ -288,8 +544,9 @@ func (m Migrator) HasIndex(value interface{}, name string) bool {
 		}
 
 		if name != "" {
+			master, table := m.sqliteMaster(tableOf(stmt))
 			m.DB.Raw(
-				"SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?", "index", stmt.Table, name,
+				"SELECT count(*) FROM "+master+" WHERE type = ? AND tbl_name = ? AND name = ?", "index", table, name,
 			).Row().Scan(&count)
 		}
 		return nil
@@ -300,12 +557,17 @@ func (m Migrator) HasIndex(value interface{}, name string) bool {
 func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		var sql string
-		m.DB.Raw("SELECT sql FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?", "index", stmt.Table, oldName).Row().Scan(&sql)
+		master, table := m.sqliteMaster(tableOf(stmt))
+		m.DB.Raw("SELECT sql FROM "+master+" WHERE type = ? AND tbl_name = ? AND name = ?", "index", table, oldName).Row().Scan(&sql)
 		if sql != "" {
 			if err := m.DropIndex(value, oldName); err != nil {
 				return err
 			}
-			return m.DB.Exec(strings.Replace(sql, oldName, newName, 1)).Error
+			sql = strings.Replace(sql, oldName, newName, 1)
+			if schema, _ := splitTable(tableOf(stmt)); schema != "" {
+				sql = createObjectRegexp.ReplaceAllString(sql, "${1}"+m.DB.Statement.Quote(schema)+".")
+			}
+			return m.DB.Exec(sql).Error
 		}
 		return fmt.Errorf("failed to find index with name %v", oldName)
 	})
@@ -319,10 +581,15 @@ func (m Migrator) DropIndex(value interface{}, name string) error {
 			}
 		}
 
-		return m.DB.Exec("DROP INDEX ?", clause.Column{Name: name}).Error
+		schema, _ := splitTable(tableOf(stmt))
+		return m.DB.Exec("DROP INDEX ?", clause.Column{Table: schema, Name: name}).Error
 	})
 }
 
+// createObjectRegexp matches the head of the sql of an index, trigger or view up to its name,
+// to qualify the name with the schema of an attached database.
+var createObjectRegexp = regexp.MustCompile(`^(?i)(CREATE\s+(?:UNIQUE\s+|TEMP\s+|TEMPORARY\s+)?(?:INDEX|TRIGGER|VIEW)\s+(?:IF\s+NOT\s+EXISTS\s+)?)`)
+
 type Index struct {
 	Seq     int
 	Name    string
@@ -339,7 +606,11 @@ func (m Migrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
 	indexes := make([]gorm.Index, 0)
 	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		rst := make([]*Index, 0)
-		if err := m.DB.Debug().Raw("SELECT * FROM PRAGMA_index_list(?)", stmt.Table).Scan(&rst).Error; err != nil { // alias `PRAGMA index_list(?)`
+		schema, table := splitTable(tableOf(stmt))
+		if schema == "" {
+			schema = "main"
+		}
+		if err := m.DB.Debug().Raw("SELECT * FROM PRAGMA_index_list(?, ?)", table, schema).Scan(&rst).Error; err != nil { // alias `PRAGMA index_list(?)`
 			return err
 		}
 		for _, index := range rst {
@@ -347,7 +618,7 @@ func (m Migrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
 				continue
 			}
 			var columns []string
-			if err := m.DB.Raw("SELECT name FROM PRAGMA_index_info(?)", index.Name).Scan(&columns).Error; err != nil { // alias `PRAGMA index_info(?)`
+			if err := m.DB.Raw("SELECT name FROM PRAGMA_index_info(?, ?)", index.Name, schema).Scan(&columns).Error; err != nil { // alias `PRAGMA index_info(?)`
 				return err
 			}
 			indexes = append(indexes, &migrator.Index{
@@ -365,7 +636,8 @@ func (m Migrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
 
 func (m Migrator) getRawDDL(table string) (string, error) {
 	var createSQL string
-	m.DB.Raw("SELECT sql FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?", "table", table, table).Row().Scan(&createSQL)
+	master, table := m.sqliteMaster(table)
+	m.DB.Raw("SELECT sql FROM "+master+" WHERE type = ? AND tbl_name = ? AND name = ?", "table", table, table).Row().Scan(&createSQL)
 
 	if m.DB.Error != nil {
 		return "", m.DB.Error
@@ -373,12 +645,181 @@ func (m Migrator) getRawDDL(table string) (string, error) {
 	return createSQL, nil
 }
 
+// schemaObject is an index, trigger or view recreated together with the table it depends on.
+type schemaObject struct {
+	Type string
+	Name string
+	// TblName is the table or view of an index or trigger
+	TblName string
+	SQL     string
+}
+
+// usedColumn returns the first of columns used by an index, in its key or its WHERE clause, if any.
+func (object schemaObject) usedColumn(columns []string) string {
+	definition := object.SQL
+	if matches := indexRegexp.FindStringSubmatch(definition); matches != nil {
+		definition = matches[1]
+	}
+	// the name of the table is skipped
+	if _, rest, ok := strings.Cut(definition, "("); ok {
+		definition = rest
+	}
+
+	for _, column := range columns {
+		if refersTo(definition, []string{column}) {
+			return column
+		}
+	}
+	return ""
+}
+
+// dependentObjects returns the indexes and triggers of table, the views referring to it directly or through
+// other views, and the triggers of other tables and views referring to it, in the order they were created.
+func (m Migrator) dependentObjects(tx *gorm.DB, table string) ([]schemaObject, error) {
+	master, name := m.sqliteMaster(table)
+
+	var objects []schemaObject
+	if err := tx.Raw(
+		"SELECT type, name, tbl_name, sql FROM "+master+" WHERE sql IS NOT NULL AND ((type = ? AND tbl_name = ?) OR type IN ?) ORDER BY rowid",
+		"index", name, []string{"trigger", "view"},
+	).Scan(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	// views may refer to the table through other views, which are created before them
+	dependent := make([]bool, len(objects))
+	referred := []string{name}
+	for i, object := range objects {
+		switch object.Type {
+		case "index":
+			dependent[i] = true
+		case "view":
+			if refersTo(object.SQL, referred) {
+				dependent[i] = true
+				referred = append(referred, object.Name)
+			}
+		}
+	}
+	for i, object := range objects {
+		if object.Type == "trigger" {
+			dependent[i] = slices.ContainsFunc(referred, func(table string) bool {
+				return strings.EqualFold(table, object.TblName)
+			}) || refersTo(object.SQL, referred)
+		}
+	}
+
+	var dependents []schemaObject
+	for i, object := range objects {
+		if dependent[i] {
+			dependents = append(dependents, object)
+		}
+	}
+	return dependents, nil
+}
+
+func refersTo(sql string, tables []string) bool {
+	for _, table := range tables {
+		if regexp.MustCompile(`(?i)(^|[^\w])` + quotedIdentifier(table) + `([^\w]|$)`).MatchString(sql) {
+			return true
+		}
+	}
+	return false
+}
+
+// recreateObject creates object again once table is recreated, and checks that it still fits the table as
+// SQLite doesn't check the columns used by triggers and views until they run.
+func (m Migrator) recreateObject(tx *gorm.DB, object schemaObject, table string, renamed map[string]string) error {
+	schema, name := splitTable(table)
+	quote := tx.Statement.Quote
+
+	sql := object.SQL
+	switch object.Type {
+	case "index":
+		for column, previous := range renamed {
+			sql = renameReferences(sql, previous, column)
+		}
+	case "trigger":
+		// NEW and OLD refer to the table of the trigger, which may be another one
+		if strings.EqualFold(object.TblName, name) {
+			for column, previous := range renamed {
+				sql = renameTriggerReferences(sql, previous, column)
+			}
+		}
+	}
+	if schema != "" {
+		sql = createObjectRegexp.ReplaceAllString(sql, "${1}"+quote(schema)+".")
+	}
+	if err := tx.Exec(sql).Error; err != nil {
+		return err
+	}
+	object.SQL = sql
+
+	prefix := strings.TrimSuffix(table, name)
+	switch object.Type {
+	case "view":
+		return tx.Exec("EXPLAIN SELECT * FROM " + quote(prefix+object.Name)).Error
+	case "trigger":
+		return checkTrigger(tx, object, prefix)
+	}
+	return nil
+}
+
+var triggerEventRegexp = regexp.MustCompile(fmt.Sprintf(
+	`(?is)^CREATE\s+(?:TEMP(?:ORARY)?\s+)?TRIGGER\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:%[1]s\.)?%[1]s\s+(?:BEFORE\s+|AFTER\s+|INSTEAD\s+OF\s+)?(INSERT|UPDATE|DELETE)\b`,
+	"[`\"[]?[\\w-]+[`\"\\]]?",
+))
+
+// checkTrigger compiles a statement firing trigger, prefix qualifies its table with the schema if any.
+// Compiling it fails when the trigger uses a missing column or table, nothing is run.
+func checkTrigger(tx *gorm.DB, trigger schemaObject, prefix string) error {
+	matches := triggerEventRegexp.FindStringSubmatch(trigger.SQL)
+	if matches == nil {
+		return nil
+	}
+
+	quote := tx.Statement.Quote
+	target := quote(prefix + trigger.TblName)
+	var statement string
+	switch strings.ToUpper(matches[1]) {
+	case "INSERT":
+		statement = "INSERT INTO " + target + " DEFAULT VALUES"
+	case "DELETE":
+		statement = "DELETE FROM " + target
+	case "UPDATE":
+		schema := strings.TrimSuffix(prefix, ".")
+		if schema == "" {
+			schema = "main"
+		}
+		var columns []string
+		if err := tx.Raw("SELECT name FROM pragma_table_xinfo(?, ?) WHERE hidden = 0", trigger.TblName, schema).Scan(&columns).Error; err != nil {
+			return err
+		}
+
+		// a trigger on missing columns wouldn't fire any more, SQLite doesn't report it
+		if of := triggerOfRegexp.FindStringSubmatch(trigger.SQL); of != nil {
+			for _, column := range strings.Split(of[2], ",") {
+				column = strings.Trim(strings.TrimSpace(column), "`\"[]")
+				if !slices.ContainsFunc(columns, func(name string) bool { return strings.EqualFold(name, column) }) {
+					return fmt.Errorf("no such column: %v", column)
+				}
+			}
+		}
+
+		assignments := make([]string, len(columns))
+		for i, column := range columns {
+			assignments[i] = quote(column) + " = " + quote(column)
+		}
+		statement = "UPDATE " + target + " SET " + strings.Join(assignments, ", ")
+	}
+	return tx.Exec("EXPLAIN " + statement).Error
+}
+
 func (m Migrator) recreateTable(
 	value interface{}, tablePtr *string,
 	getCreateSQL func(ddl *ddl, stmt *gorm.Statement) (sql *ddl, sqlArgs []interface{}, err error),
 ) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		table := stmt.Table
+		table := tableOf(stmt)
 		if tablePtr != nil {
 			table = *tablePtr
 		}
@@ -401,30 +842,112 @@ func (m Migrator) recreateTable(
 			return nil
 		}
 
+		// the temp table is created next to the original one, which keeps its unqualified name in the DDL
+		_, name := splitTable(table)
 		newTableName := table + "__temp"
-		if err := createDDL.renameTable(newTableName, table); err != nil {
+		if err := createDDL.renameTable(newTableName, name); err != nil {
 			return err
 		}
 
 		columns := createDDL.getColumns()
 		createSQL := createDDL.compile()
 
-		return m.DB.Transaction(func(tx *gorm.DB) error {
-			if err := tx.Exec(createSQL, sqlArgs...).Error; err != nil {
-				return err
+		// renamed columns are copied from their previous name
+		selectColumns := make([]string, len(columns))
+		for i, column := range columns {
+			selectColumns[i] = column
+			if previous, ok := createDDL.renamed[strings.Trim(column, "`")]; ok {
+				selectColumns[i] = "`" + previous + "`"
 			}
+		}
 
-			queries := []string{
-				fmt.Sprintf("INSERT INTO `%v`(%v) SELECT %v FROM `%v`", newTableName, strings.Join(columns, ","), strings.Join(columns, ","), table),
-				fmt.Sprintf("DROP TABLE `%v`", table),
-				fmt.Sprintf("ALTER TABLE `%v` RENAME TO `%v`", newTableName, table),
-			}
-			for _, query := range queries {
-				if err := tx.Exec(query).Error; err != nil {
+		// https://www.sqlite.org/lang_altertable.html#otheralter
+		return m.withoutForeignKeys(func(enabled bool) error {
+			return m.DB.Transaction(func(tx *gorm.DB) error {
+				dependents, err := m.dependentObjects(tx, table)
+				if err != nil {
 					return err
 				}
-			}
-			return nil
+				schema, _ := splitTable(table)
+				catalog := schema
+				if catalog == "" {
+					catalog = "main"
+				}
+				var previous []string
+				if err := tx.Raw("SELECT name FROM pragma_table_xinfo(?, ?)", name, catalog).Scan(&previous).Error; err != nil {
+					return err
+				}
+
+				if err := tx.Exec(createSQL, sqlArgs...).Error; err != nil {
+					return err
+				}
+
+				quote := tx.Statement.Quote
+				prefix := strings.TrimSuffix(table, name)
+				var queries []string
+				// views and the triggers of other tables are dropped first, the rename fails while they refer to a missing table
+				for i := len(dependents) - 1; i >= 0; i-- {
+					switch object := dependents[i]; {
+					case object.Type == "view":
+						queries = append(queries, fmt.Sprintf("DROP VIEW %v", quote(prefix+object.Name)))
+					case object.Type == "trigger" && !strings.EqualFold(object.TblName, name):
+						queries = append(queries, fmt.Sprintf("DROP TRIGGER %v", quote(prefix+object.Name)))
+					}
+				}
+				queries = append(queries,
+					fmt.Sprintf("INSERT INTO %v(%v) SELECT %v FROM %v", quote(newTableName), strings.Join(columns, ","), strings.Join(selectColumns, ","), quote(table)),
+					fmt.Sprintf("DROP TABLE %v", quote(table)),
+					fmt.Sprintf("ALTER TABLE %v RENAME TO %v", quote(newTableName), quote(name)),
+				)
+				for _, query := range queries {
+					if err := tx.Exec(query).Error; err != nil {
+						return err
+					}
+				}
+
+				// the objects are recreated in their original order, triggers last as they may use the views, and
+				// the indexes using a dropped column are dropped with it
+				var recreated []string
+				if err := tx.Raw("SELECT name FROM pragma_table_xinfo(?, ?)", name, catalog).Scan(&recreated).Error; err != nil {
+					return err
+				}
+				var removed []string
+				for _, column := range previous {
+					if !slices.ContainsFunc(recreated, func(kept string) bool {
+						return strings.EqualFold(column, kept) || strings.EqualFold(column, createDDL.renamed[kept])
+					}) {
+						removed = append(removed, column)
+					}
+				}
+				for _, objectType := range []string{"index", "view", "trigger"} {
+					for _, object := range dependents {
+						if object.Type != objectType {
+							continue
+						}
+						if object.Type == "index" {
+							if column := object.usedColumn(removed); column != "" {
+								tx.Logger.Info(tx.Statement.Context, "sqlite: index %v is dropped with column %v", object.Name, column)
+								continue
+							}
+						}
+						if err := m.recreateObject(tx, object, table, createDDL.renamed); err != nil {
+							return fmt.Errorf("failed to recreate %v %v: %w", object.Type, object.Name, err)
+						}
+					}
+				}
+
+				// the enforcement is off while the table is recreated, rows left without parent are reported instead
+				if enabled {
+					violations, err := checkForeignKeys(tx.Statement.Context, tx.Statement.ConnPool, catalog, name)
+					if err != nil {
+						return err
+					}
+					if len(violations) > 0 {
+						return &ForeignKeyCheckError{Table: table, Violations: violations}
+					}
+				}
+				return nil
+			})
 		})
 	})
 }
@@ -0,0 +1,564 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordStatements returns the statements run by db through Exec from now on.
+func recordStatements(db *gorm.DB) *[]string {
+	var statements []string
+	db.Callback().Raw().After("gorm:raw").Register("test:record_statements", func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	})
+	return &statements
+}
+
+func containsStatement(statements []string, substr string) bool {
+	for _, statement := range statements {
+		if strings.Contains(statement, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+type NativeColumn struct {
+	ID   uint
+	Name string `gorm:"index"`
+	Note string
+	Code string
+}
+
+func TestMigratorNativeColumns(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("migratornativecolumns"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&NativeColumn{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	db.Create(&NativeColumn{Name: "bolt", Note: "m6", Code: "B-1"})
+	m := db.Migrator()
+
+	statements := recordStatements(db)
+	if err := m.DropColumn(&NativeColumn{}, "Note"); err != nil || m.HasColumn(&NativeColumn{}, "Note") {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if !containsStatement(*statements, "DROP COLUMN `note`") || containsStatement(*statements, "__temp") {
+		t.Errorf("Expected the column to be dropped natively, got %v", *statements)
+	}
+
+	// indexed columns can't be dropped natively
+	*statements = nil
+	if err := m.DropColumn(&NativeColumn{}, "Name"); err != nil || m.HasColumn(&NativeColumn{}, "Name") {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if !containsStatement(*statements, "__temp") {
+		t.Errorf("Expected the table to be recreated, got %v", *statements)
+	}
+
+	*statements = nil
+	if err := m.RenameColumn(&NativeColumn{}, "code", "sku"); err != nil || !m.HasColumn(&NativeColumn{}, "sku") {
+		t.Fatalf("failed to rename column, got error: %v", err)
+	}
+	if !containsStatement(*statements, "RENAME COLUMN `code` TO `sku`") {
+		t.Errorf("Expected the column to be renamed natively, got %v", *statements)
+	}
+
+	// older SQLite versions recreate the table, and copy the rows of renamed columns
+	db.Dialector.(*Dialector).capabilities.RenameColumn = false
+	m = db.Migrator()
+	*statements = nil
+	if err := m.RenameColumn(&NativeColumn{}, "sku", "code"); err != nil || !m.HasColumn(&NativeColumn{}, "code") || m.HasColumn(&NativeColumn{}, "sku") {
+		t.Fatalf("failed to rename column, got error: %v", err)
+	}
+	if !containsStatement(*statements, "__temp") {
+		t.Errorf("Expected the table to be recreated, got %v", *statements)
+	}
+
+	var code string
+	if err := db.Raw("SELECT code FROM native_columns").Scan(&code).Error; err != nil || code != "B-1" {
+		t.Errorf("Expected the renamed column to keep its values, got %q, error: %v", code, err)
+	}
+}
+
+type DependentItem struct {
+	ID    uint
+	Code  string `gorm:"uniqueIndex"`
+	Note  string `gorm:"index"`
+	Price int
+}
+
+func TestMigratorRecreateDependents(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("migratorrecreatedependents"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&DependentItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	for _, query := range []string{
+		"CREATE TABLE item_logs (code TEXT)",
+		"CREATE TRIGGER log_items AFTER INSERT ON dependent_items BEGIN INSERT INTO item_logs VALUES (new.code); END",
+		"CREATE VIEW cheap_items AS SELECT code, price FROM dependent_items WHERE price < 10",
+		"CREATE VIEW cheap_codes AS SELECT code FROM cheap_items",
+	} {
+		if err := db.Exec(query).Error; err != nil {
+			t.Fatalf("failed to run %q, got error: %v", query, err)
+		}
+	}
+
+	m := db.Migrator()
+	type DependentItem struct {
+		ID    uint
+		Code  string `gorm:"uniqueIndex"`
+		Note  string `gorm:"index"`
+		Price float64
+	}
+	if err := m.AlterColumn(&DependentItem{}, "Price"); err != nil {
+		t.Fatalf("failed to alter column, got error: %v", err)
+	}
+	if !m.HasIndex(&DependentItem{}, "Code") || !m.HasIndex(&DependentItem{}, "Note") {
+		t.Errorf("Expected the indexes to be recreated")
+	}
+
+	db.Dialector.(*Dialector).capabilities.RenameColumn = false
+	m = db.Migrator()
+	if err := m.RenameColumn(&DependentItem{}, "note", "remark"); err != nil {
+		t.Fatalf("failed to rename column, got error: %v", err)
+	}
+	var columns []string
+	db.Raw("SELECT name FROM pragma_index_info(?)", "idx_dependent_items_note").Scan(&columns)
+	if len(columns) != 1 || columns[0] != "remark" {
+		t.Errorf("Expected the index to follow the renamed column, got %v", columns)
+	}
+
+	// the index of the dropped column is dropped with it
+	if err := m.DropColumn(&DependentItem{}, "remark"); err != nil {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if m.HasIndex(&DependentItem{}, "idx_dependent_items_note") || !m.HasIndex(&DependentItem{}, "Code") {
+		t.Errorf("Expected only the index of the remaining column to be kept")
+	}
+
+	if err := db.Omit("Note").Create(&DependentItem{Code: "A-1", Price: 2.5}).Error; err != nil {
+		t.Fatalf("failed to create, got error: %v", err)
+	}
+	if err := db.Omit("Note").Create(&DependentItem{Code: "A-1"}).Error; err == nil {
+		t.Errorf("Expected the unique index to be enforced")
+	}
+
+	var logs, cheap int64
+	db.Table("item_logs").Count(&logs)
+	db.Table("cheap_codes").Count(&cheap)
+	if logs != 1 || cheap != 1 {
+		t.Errorf("Expected the trigger and the views to be recreated, got %d logs and %d cheap items", logs, cheap)
+	}
+}
+
+func TestMigratorRenameColumnTriggers(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("migratorrenamecolumntriggers"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+	db.Dialector.(*Dialector).capabilities.RenameColumn = false
+
+	if err := db.AutoMigrate(&DependentItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	for _, query := range []string{
+		"CREATE TABLE item_logs (code TEXT, note TEXT)",
+		"CREATE TRIGGER log_items AFTER UPDATE OF code, note ON dependent_items WHEN new.\"code\" <> 'code' BEGIN INSERT INTO item_logs VALUES (NEW.code, old.note); END",
+	} {
+		if err := db.Exec(query).Error; err != nil {
+			t.Fatalf("failed to run %q, got error: %v", query, err)
+		}
+	}
+
+	m := db.Migrator()
+	if err := m.RenameColumn(&DependentItem{}, "code", "sku"); err != nil {
+		t.Fatalf("failed to rename column, got error: %v", err)
+	}
+	db.Exec("INSERT INTO dependent_items (note) VALUES (?)", "a")
+	if err := db.Exec("UPDATE dependent_items SET sku = ?", "A-1").Error; err != nil {
+		t.Fatalf("Expected the trigger to follow the renamed column, got error: %v", err)
+	}
+	var logs []map[string]interface{}
+	db.Table("item_logs").Find(&logs)
+	if len(logs) != 1 || logs[0]["code"] != "A-1" || logs[0]["note"] != "a" {
+		t.Errorf("Expected the trigger to log the update, got %v", logs)
+	}
+
+	// columns used outside of NEW, OLD and UPDATE OF can't be told from those of other tables, they aren't renamed
+	if err := db.Exec("CREATE TRIGGER clear_items AFTER DELETE ON dependent_items BEGIN UPDATE dependent_items SET note = ''; END").Error; err != nil {
+		t.Fatalf("failed to create trigger, got error: %v", err)
+	}
+	err = m.RenameColumn(&DependentItem{}, "note", "remark")
+	if err == nil || !strings.Contains(err.Error(), "failed to recreate trigger clear_items") || !strings.Contains(err.Error(), "no such column: note") {
+		t.Fatalf("Expected the trigger to be reported, got %v", err)
+	}
+	if !m.HasColumn(&DependentItem{}, "note") {
+		t.Errorf("Expected the rename to be rolled back")
+	}
+}
+
+type UnfitItem struct {
+	ID   uint
+	Code string `gorm:"index"`
+	Flag int
+	Note string
+}
+
+func TestMigratorRecreateUnfitObjects(t *testing.T) {
+	var logs recordedLogs
+	db, err := gorm.Open(OpenMemory("migratorrecreateunfitobjects"), &gorm.Config{
+		Logger: logger.New(&logs, logger.Config{LogLevel: logger.Info}),
+	})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&UnfitItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	for _, query := range []string{
+		"CREATE TABLE unfit_logs (code TEXT)",
+		"CREATE INDEX idx_unfit_items_coded ON unfit_items(note) WHERE code <> ''",
+		"CREATE INDEX idx_unfit_items_flagged ON unfit_items(id) WHERE flag > 0",
+		"INSERT INTO unfit_items (code, flag, note) VALUES ('A-1', 1, 'a')",
+	} {
+		if err := db.Exec(query).Error; err != nil {
+			t.Fatalf("failed to run %q, got error: %v", query, err)
+		}
+	}
+
+	// triggers and views using a dropped column would be left broken, the table isn't recreated
+	m := db.Migrator()
+	for _, object := range []struct{ name, sql string }{
+		{"trigger log_unfit_items", "CREATE TRIGGER log_unfit_items AFTER UPDATE ON unfit_items BEGIN INSERT INTO unfit_logs VALUES (NEW.code); END"},
+		{"trigger watch_unfit_items", "CREATE TRIGGER watch_unfit_items AFTER UPDATE OF code ON unfit_items BEGIN SELECT 1; END"},
+		{"view unfit_codes", "CREATE VIEW unfit_codes AS SELECT code FROM unfit_items"},
+	} {
+		if err := db.Exec(object.sql).Error; err != nil {
+			t.Fatalf("failed to create %v, got error: %v", object.name, err)
+		}
+		err := m.DropColumn(&UnfitItem{}, "Code")
+		if err == nil || !strings.Contains(err.Error(), "failed to recreate "+object.name+": ") || !strings.Contains(err.Error(), "no such column") {
+			t.Fatalf("Expected the %v to be reported, got %v", object.name, err)
+		}
+		if !m.HasColumn(&UnfitItem{}, "Code") || !m.HasIndex(&UnfitItem{}, "idx_unfit_items_coded") {
+			t.Fatalf("Expected the table to be kept")
+		}
+		kind, name, _ := strings.Cut(object.name, " ")
+		db.Exec("DROP " + kind + " " + name)
+	}
+
+	// the indexes using a dropped column are dropped with it, by their key or their WHERE clause
+	logs = nil
+	if err := m.DropColumn(&UnfitItem{}, "Code"); err != nil || m.HasColumn(&UnfitItem{}, "Code") {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if m.HasIndex(&UnfitItem{}, "idx_unfit_items_code") || m.HasIndex(&UnfitItem{}, "idx_unfit_items_coded") || !m.HasIndex(&UnfitItem{}, "idx_unfit_items_flagged") {
+		t.Errorf("Expected only the indexes using the column to be dropped")
+	}
+	for _, index := range []string{"idx_unfit_items_code", "idx_unfit_items_coded"} {
+		if !slices.ContainsFunc(logs, func(log string) bool { return strings.Contains(log, "index "+index+" is dropped with column code") }) {
+			t.Errorf("Expected the dropped index %v to be logged, got %v", index, logs)
+		}
+	}
+
+	// SQLite refuses to drop a column used by a partial index, the table is recreated instead
+	statements := recordStatements(db)
+	if err := m.DropColumn(&UnfitItem{}, "Flag"); err != nil || m.HasColumn(&UnfitItem{}, "Flag") {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if !containsStatement(*statements, "__temp") || m.HasIndex(&UnfitItem{}, "idx_unfit_items_flagged") {
+		t.Errorf("Expected the table to be recreated without the index, got %v", *statements)
+	}
+
+	var note string
+	if err := db.Raw("SELECT note FROM unfit_items").Scan(&note).Error; err != nil || note != "a" {
+		t.Errorf("Expected the row to be kept, got %q, error: %v", note, err)
+	}
+}
+
+func TestMigratorRecreateReferringTriggers(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("migratorrecreatereferringtriggers"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	if err := db.AutoMigrate(&DependentItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	for _, query := range []string{
+		"CREATE TABLE item_sources (code TEXT)",
+		"CREATE TRIGGER copy_sources AFTER INSERT ON item_sources BEGIN INSERT INTO dependent_items (code, note, price) VALUES (new.code, '', 0); END",
+		"CREATE VIEW item_codes AS SELECT id, code FROM dependent_items",
+		"CREATE TRIGGER insert_item_codes INSTEAD OF INSERT ON item_codes BEGIN INSERT INTO dependent_items (code, note, price) VALUES (new.code, '', 1); END",
+	} {
+		if err := db.Exec(query).Error; err != nil {
+			t.Fatalf("failed to run %q, got error: %v", query, err)
+		}
+	}
+
+	// triggers of other tables and views writing to the table are recreated with it
+	type DependentItem struct {
+		ID    uint
+		Code  string `gorm:"uniqueIndex"`
+		Note  string `gorm:"index"`
+		Price float64
+	}
+	if err := db.Migrator().AlterColumn(&DependentItem{}, "Price"); err != nil {
+		t.Fatalf("failed to alter column, got error: %v", err)
+	}
+	if err := db.Exec("INSERT INTO item_sources VALUES ('A-1')").Error; err != nil {
+		t.Fatalf("failed to insert, got error: %v", err)
+	}
+	if err := db.Exec("INSERT INTO item_codes (code) VALUES ('B-1')").Error; err != nil {
+		t.Fatalf("failed to insert, got error: %v", err)
+	}
+
+	var codes []string
+	db.Model(&DependentItem{}).Order("code").Pluck("code", &codes)
+	if !slices.Equal(codes, []string{"A-1", "B-1"}) {
+		t.Errorf("Expected the triggers to be recreated, got %v", codes)
+	}
+}
+
+type CheckedParent struct {
+	ID       uint
+	Name     string
+	Children []CheckedChild `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+}
+
+type CheckedChild struct {
+	ID       uint
+	ParentID uint
+	Note     string
+}
+
+func TestMigratorRecreateForeignKeyCheck(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:     "file:migratorrecreateforeignkeycheck?mode=memory&cache=shared",
+		Pragmas: &Pragmas{ForeignKeys: &[]bool{true}[0]},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&CheckedParent{}, &CheckedChild{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	parent := CheckedParent{Name: "bolts"}
+	db.Create(&parent)
+	db.Create(&CheckedChild{ParentID: parent.ID})
+
+	// recreating a parent keeps the rows of its children
+	if err := db.Migrator().AlterColumn(&CheckedParent{}, "Name"); err != nil {
+		t.Fatalf("failed to alter column, got error: %v", err)
+	}
+
+	// the enforcement can't be turned off within a transaction, where dropping the parent would delete its children
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Migrator().AlterColumn(&CheckedParent{}, "Name")
+	})
+	if !errors.Is(err, ErrForeignKeysInTransaction) {
+		t.Fatalf("Expected ErrForeignKeysInTransaction, got %v", err)
+	}
+	var children int64
+	db.Model(&CheckedChild{}).Count(&children)
+	if children != 1 {
+		t.Fatalf("Expected the children to be kept, got %d", children)
+	}
+
+	db.Exec("PRAGMA foreign_keys = OFF")
+	db.Create(&CheckedChild{ID: 10, ParentID: 42})
+	db.Exec("PRAGMA foreign_keys = ON")
+
+	err = db.Migrator().AlterColumn(&CheckedChild{}, "Note")
+	var checkErr *ForeignKeyCheckError
+	if !errors.As(err, &checkErr) || !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Fatalf("Expected a ForeignKeyCheckError, got %v", err)
+	}
+	if len(checkErr.Violations) != 1 || checkErr.Violations[0] != (ForeignKeyViolation{Table: "checked_children", RowID: 10, Parent: "checked_parents"}) {
+		t.Errorf("Expected the orphaned row to be reported, got %+v", checkErr.Violations)
+	}
+
+	var enabled int
+	db.Raw("PRAGMA foreign_keys").Scan(&enabled)
+	if enabled != 1 {
+		t.Errorf("Expected foreign keys to be enabled again")
+	}
+}
+
+func TestMigratorPinnedConnection(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:     "file:migratorpinnedconnection?mode=memory&cache=shared",
+		Pragmas: &Pragmas{ForeignKeys: &[]bool{true}[0]},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+	sqlDB, _ := db.DB()
+
+	m := db.Migrator().(Migrator)
+	if err := m.RunWithoutForeignKey(func() error {
+		// hold another connection, the statements of the migrator keep running on theirs
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var enabled int
+		if err := m.DB.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil || enabled != 0 {
+			t.Errorf("Expected foreign keys to be disabled on the connection of the migrator, got %v, error: %v", enabled, err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to run without foreign keys, got error: %v", err)
+	}
+
+	if _, ok := m.DB.Statement.ConnPool.(*sql.Conn); ok {
+		t.Errorf("Expected the migrator to be released from the connection")
+	}
+	var enabled int
+	db.Raw("PRAGMA foreign_keys").Scan(&enabled)
+	if enabled != 1 {
+		t.Errorf("Expected foreign keys to be enabled again")
+	}
+}
+
+type CatalogParent struct {
+	ID   uint
+	Code string `gorm:"unique"`
+}
+
+type CatalogChild struct {
+	ID       uint
+	Username string
+	ParentID uint
+	Parent   CatalogParent
+	Age      int `gorm:"check:adult,age >= 18"`
+}
+
+func TestMigratorCatalogLookups(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("migratorcataloglookups"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	// written by hand, names only appear in defaults, checks and other columns
+	for _, query := range []string{
+		"CREATE TABLE catalog_parents (id INTEGER PRIMARY KEY, code TEXT UNIQUE)",
+		"CREATE TABLE catalog_children (id INTEGER PRIMARY KEY, username TEXT DEFAULT 'name ', " +
+			"parent_id INTEGER REFERENCES catalog_parents(id), age INTEGER, CONSTRAINT [Adult] CHECK (age >= 18 AND username <> 'note '))",
+	} {
+		if err := db.Exec(query).Error; err != nil {
+			t.Fatalf("failed to run %q, got error: %v", query, err)
+		}
+	}
+
+	m := db.Migrator()
+	if m.HasColumn(&CatalogChild{}, "name") || m.HasColumn(&CatalogChild{}, "note") {
+		t.Errorf("Expected columns only mentioned in defaults and checks not to be found")
+	}
+	if !m.HasColumn(&CatalogChild{}, "USERNAME") || !m.HasColumn(&CatalogChild{}, "Username") {
+		t.Errorf("Expected columns to be found case insensitively")
+	}
+
+	if !m.HasConstraint(&CatalogChild{}, "Parent") || !m.HasConstraint(&CatalogChild{}, "fk_catalog_children_parent") {
+		t.Errorf("Expected the unnamed foreign key to be found")
+	}
+	if !m.HasConstraint(&CatalogParent{}, "Code") {
+		t.Errorf("Expected the unique constraint to be found")
+	}
+	if !m.HasConstraint(&CatalogChild{}, "adult") || !m.HasConstraint(&CatalogChild{}, "Age") {
+		t.Errorf("Expected the check constraint to be found case insensitively")
+	}
+	if m.HasConstraint(&CatalogChild{}, "fk_missing") {
+		t.Errorf("Expected unknown constraints not to be found")
+	}
+
+	// the foreign key of a relation to another table isn't on this table
+	if err := db.Exec("CREATE TABLE other_parents (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatalf("failed to create table, got error: %v", err)
+	}
+	if err := db.Exec("DROP TABLE catalog_children").Error; err != nil {
+		t.Fatalf("failed to drop table, got error: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE catalog_children (id INTEGER PRIMARY KEY, username TEXT, parent_id INTEGER REFERENCES other_parents(id), age INTEGER)").Error; err != nil {
+		t.Fatalf("failed to create table, got error: %v", err)
+	}
+	if m.HasConstraint(&CatalogChild{}, "Parent") {
+		t.Errorf("Expected a foreign key referencing another table not to match")
+	}
+}
+
+type recordedLogs []string
+
+func (logs *recordedLogs) Printf(format string, args ...interface{}) {
+	*logs = append(*logs, fmt.Sprintf(format, args...))
+}
+
+func TestMigratorDropColumnNative(t *testing.T) {
+	var logs recordedLogs
+	db, err := gorm.Open(OpenMemory("migratordropcolumnnative"), &gorm.Config{
+		Logger: logger.New(&logs, logger.Config{LogLevel: logger.Info}),
+	})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	type PricedItem struct {
+		ID    uint
+		Note  string
+		Price int
+	}
+	if err := db.AutoMigrate(&PricedItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	if err := db.Exec("CREATE TRIGGER priced_items_updated AFTER UPDATE ON priced_items BEGIN SELECT new.price; END").Error; err != nil {
+		t.Fatalf("failed to create trigger, got error: %v", err)
+	}
+
+	// SQLite refuses to drop a column used by a trigger, the table isn't recreated with a broken trigger
+	m := db.Migrator()
+	if err := m.DropColumn(&PricedItem{}, "Price"); err == nil || !strings.Contains(err.Error(), "priced_items_updated") {
+		t.Fatalf("Expected the trigger to be reported, got %v", err)
+	}
+	if !m.HasColumn(&PricedItem{}, "Price") {
+		t.Errorf("Expected the column to be kept")
+	}
+
+	logs = nil
+	if err := m.DropColumn(&PricedItem{}, "Note"); err != nil || m.HasColumn(&PricedItem{}, "Note") {
+		t.Fatalf("failed to drop column, got error: %v", err)
+	}
+	if !slices.ContainsFunc(logs, func(log string) bool { return strings.Contains(log, "ALTER TABLE `priced_items` DROP COLUMN `note`") }) {
+		t.Errorf("Expected the statement to be logged, got %v", logs)
+	}
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pragmas are applied to every connection opened by the Dialector, zero values keep the SQLite defaults.
+// See https://www.sqlite.org/pragma.html for the meaning of each setting.
+type Pragmas struct {
+	// JournalMode is one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF.
+	JournalMode string
+	// Synchronous is one of OFF, NORMAL, FULL or EXTRA.
+	Synchronous string
+	// BusyTimeout is applied before any other pragma, so the remaining ones can wait for locks.
+	BusyTimeout time.Duration
+	ForeignKeys *bool
+	// CacheSize is a number of pages when positive, or a size in KiB when negative.
+	CacheSize int
+	// TempStore is one of DEFAULT, FILE or MEMORY.
+	TempStore string
+	MmapSize  int64
+}
+
+var pragmaKeywords = map[string][]string{
+	"journal_mode": {"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"},
+	"synchronous":  {"OFF", "NORMAL", "FULL", "EXTRA"},
+	"temp_store":   {"DEFAULT", "FILE", "MEMORY"},
+}
+
+// statements returns the PRAGMA statements to run on a new connection.
+func (p Pragmas) statements() ([]string, error) {
+	var stmts []string
+
+	if p.BusyTimeout > 0 {
+		stmts = append(stmts, "PRAGMA busy_timeout = "+strconv.FormatInt(p.BusyTimeout.Milliseconds(), 10))
+	}
+
+	for _, kv := range [][2]string{{"journal_mode", p.JournalMode}, {"synchronous", p.Synchronous}, {"temp_store", p.TempStore}} {
+		if kv[1] == "" {
+			continue
+		}
+		value, err := pragmaKeyword(kv[0], kv[1])
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, "PRAGMA "+kv[0]+" = "+value)
+	}
+
+	if p.ForeignKeys != nil {
+		if *p.ForeignKeys {
+			stmts = append(stmts, "PRAGMA foreign_keys = ON")
+		} else {
+			stmts = append(stmts, "PRAGMA foreign_keys = OFF")
+		}
+	}
+
+	if p.CacheSize != 0 {
+		stmts = append(stmts, "PRAGMA cache_size = "+strconv.Itoa(p.CacheSize))
+	}
+
+	if p.MmapSize != 0 {
+		stmts = append(stmts, "PRAGMA mmap_size = "+strconv.FormatInt(p.MmapSize, 10))
+	}
+
+	return stmts, nil
+}
+
+func pragmaKeyword(name, value string) (string, error) {
+	for _, keyword := range pragmaKeywords[name] {
+		if strings.EqualFold(keyword, value) {
+			return keyword, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value %q for pragma %v", value, name)
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// readOnlyDSN opens the configured DSN with mode=ro, and immutable=1 for Immutable or FS.
+func (dialector Dialector) readOnlyDSN() (string, error) {
+	dsn, err := ParseDSN(dialector.DSN)
+	if err != nil {
+		return "", err
+	}
+	if !dsn.Memory && dsn.Mode != "memory" {
+		dsn.Mode = "ro"
+	}
+	dsn.Immutable = dsn.Immutable || dialector.Immutable || dialector.FS != nil
+	return dsn.String(), nil
+}
+
+func (dialector Dialector) readOnly() bool {
+	return dialector.ReadOnly || dialector.Immutable || dialector.FS != nil
+}
+
+// registerReadOnly fails creates, updates and deletes with ErrReadOnly before a transaction is started.
+func registerReadOnly(db *gorm.DB) error {
+	reject := func(db *gorm.DB) {
+		db.AddError(ErrReadOnly)
+	}
+
+	if err := db.Callback().Create().Before("gorm:begin_transaction").Register("sqlite:read_only", reject); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:begin_transaction").Register("sqlite:read_only", reject); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:begin_transaction").Register("sqlite:read_only", reject)
+}
+
+// readOnlyMigrator refuses every DDL statement with ErrReadOnly, the inspection methods are inherited.
+type readOnlyMigrator struct {
+	Migrator
+}
+
+func (readOnlyMigrator) AutoMigrate(dst ...interface{}) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) CreateTable(dst ...interface{}) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) DropTable(dst ...interface{}) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) RenameTable(oldName, newName interface{}) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) AddColumn(dst interface{}, field string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) DropColumn(dst interface{}, field string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) AlterColumn(dst interface{}, field string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) MigrateColumn(dst interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) MigrateColumnUnique(dst interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) RenameColumn(dst interface{}, oldName, field string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) CreateView(name string, option gorm.ViewOption) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) DropView(name string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) CreateConstraint(dst interface{}, name string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) DropConstraint(dst interface{}, name string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) CreateIndex(dst interface{}, name string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) DropIndex(dst interface{}, name string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyMigrator) RenameIndex(dst interface{}, oldName, newName string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// validateReadPool rejects a ReadPoolSize the Dialector can't honour: pools are only opened without Conn,
+// and the readers must see the database of the writer, which private in-memory databases are not.
+func (dialector Dialector) validateReadPool() error {
+	if dialector.ReadPoolSize <= 0 {
+		return nil
+	}
+	if dialector.Conn != nil {
+		return errors.New("sqlite: ReadPoolSize can't be used with Conn")
+	}
+
+	dsn, err := ParseDSN(dialector.DSN)
+	if err != nil {
+		return err
+	}
+	temporary := dsn.Path == "" && !dsn.Memory
+	private := (dsn.Memory || dsn.Mode == "memory") && dsn.Cache != "shared"
+	if temporary || private {
+		return errors.New("sqlite: ReadPoolSize needs a database shared by all connections, a file or a shared in-memory database")
+	}
+	return nil
+}
+
+// registerReadPool sends queries to the read-only pool, everything else including transactions stays on db.ConnPool.
+func registerReadPool(db *gorm.DB, readPool gorm.ConnPool) error {
+	route := func(db *gorm.DB) {
+		// statements pinned to a transaction or a connection keep using it
+		if db.Error != nil || db.Statement.ConnPool != db.ConnPool {
+			return
+		}
+
+		if db.Statement.SQL.Len() == 0 || isSelectSQL(db.Statement.SQL.String()) {
+			db.Statement.ConnPool = readPool
+		}
+	}
+
+	if err := db.Callback().Query().Before("gorm:query").Register("sqlite:read_pool", route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("sqlite:read_pool", route)
+}
+
+// isSelectSQL reports whether a raw SQL is a plain SELECT, which can't write.
+func isSelectSQL(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	return len(sql) > 6 && strings.EqualFold(sql[:6], "select") && strings.ContainsAny(sql[6:7], " \t\n\r(")
+}
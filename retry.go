@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	msqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// RetryPolicy retries statements and transactions failing with SQLITE_BUSY or SQLITE_LOCKED,
+// waiting with a jittered exponential backoff between attempts. Zero values use the defaults.
+type RetryPolicy struct {
+	// MaxElapsed bounds the time spent on all attempts of one statement or transaction, default 5s.
+	MaxElapsed time.Duration
+	// InitialInterval is the wait before the first retry, default 10ms.
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts, default 500ms.
+	MaxInterval time.Duration
+}
+
+// IsBusy reports whether err was caused by SQLITE_BUSY or SQLITE_LOCKED, e.g. another connection holding the lock.
+func IsBusy(err error) bool {
+	var sqliteErr *msqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+func (p RetryPolicy) do(ctx context.Context, log logger.Interface, fc func() error) error {
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = 5 * time.Second
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = 10 * time.Millisecond
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = 500 * time.Millisecond
+	}
+
+	var (
+		deadline = time.Now().Add(p.MaxElapsed)
+		interval = p.InitialInterval
+	)
+	for attempt := 1; ; attempt++ {
+		err := fc()
+		if err == nil || !IsBusy(err) {
+			return err
+		}
+
+		// wait between half and the full interval, so competing connections don't retry in lockstep
+		wait := interval/2 + rand.N(interval/2+1)
+		if time.Now().Add(wait).After(deadline) {
+			return err
+		}
+		if log != nil {
+			log.Warn(ctx, "sqlite: %v, retrying in %v (attempt %d)", err, wait, attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
+
+// retryPool retries the statements run outside of transactions, statements of a transaction are retried
+// by running the whole transaction again, see Transaction.
+type retryPool struct {
+	*sql.DB
+	policy RetryPolicy
+	logger logger.Interface
+}
+
+func (p *retryPool) GetDBConn() (*sql.DB, error) {
+	return p.DB, nil
+}
+
+func (p *retryPool) PrepareContext(ctx context.Context, query string) (stmt *sql.Stmt, err error) {
+	err = p.policy.do(ctx, p.logger, func() error {
+		stmt, err = p.DB.PrepareContext(ctx, query)
+		return err
+	})
+	return
+}
+
+func (p *retryPool) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	err = p.policy.do(ctx, p.logger, func() error {
+		result, err = p.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return
+}
+
+func (p *retryPool) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = p.policy.do(ctx, p.logger, func() error {
+		rows, err = p.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return
+}
+
+func (p *retryPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) (row *sql.Row) {
+	p.policy.do(ctx, p.logger, func() error {
+		row = p.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return
+}
+
+func (p *retryPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sql.Tx, err error) {
+	err = p.policy.do(ctx, p.logger, func() error {
+		tx, err = p.DB.BeginTx(ctx, opts)
+		return err
+	})
+	return
+}
+
+// registerImmediateWrites makes the transactions gorm opens around create, update and delete begin with
+// BEGIN IMMEDIATE, so a busy database fails at BEGIN where the retryPool can retry it, instead of failing
+// halfway through the transaction.
+func registerImmediateWrites(db *gorm.DB) error {
+	immediate := func(db *gorm.DB) {
+		if _, ok := db.Statement.Context.Value(txLockKey{}).(TxLock); !ok {
+			db.Statement.Context = WithTxLock(db.Statement.Context, TxImmediate)
+		}
+	}
+
+	if err := db.Callback().Create().Before("gorm:begin_transaction").Register("sqlite:immediate_write", immediate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:begin_transaction").Register("sqlite:immediate_write", immediate); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:begin_transaction").Register("sqlite:immediate_write", immediate)
+}
+
+// Transaction runs db.Transaction, and runs it again when it fails with SQLITE_BUSY or SQLITE_LOCKED
+// according to the RetryPolicy of the Dialector. Nested transactions are not retried on their own,
+// their savepoint is released once fc succeeds.
+func Transaction(db *gorm.DB, fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	dialector := dialectorOf(db)
+	if committer, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok && committer != nil && dialector != nil {
+		return nestedTransaction(db, fc)
+	}
+	if dialector == nil || dialector.Retry == nil {
+		return db.Transaction(fc, opts...)
+	}
+
+	return dialector.Retry.do(db.Statement.Context, db.Logger, func() error {
+		return db.Transaction(fc, opts...)
+	})
+}
+
+// dialectorOf returns the sqlite Dialector of db, or nil when db uses another dialector.
+func dialectorOf(db *gorm.DB) *Dialector {
+	switch dialector := db.Dialector.(type) {
+	case *Dialector:
+		return dialector
+	case Dialector:
+		return &dialector
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRetry(t *testing.T) {
+	type Item struct {
+		ID   uint
+		Name string
+	}
+
+	dsn := filepath.Join(t.TempDir(), "retry.db")
+	db, err := gorm.Open(New(Config{DSN: dsn, Retry: &RetryPolicy{MaxElapsed: 5 * time.Second}}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&Item{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	// holdLock takes the write lock with another connection and releases it a bit later
+	holdLock := func() {
+		tx := db.WithContext(WithTxLock(context.Background(), TxImmediate)).Begin()
+		if tx.Error != nil {
+			t.Fatalf("failed to begin: %v", tx.Error)
+		}
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			tx.Rollback()
+		}()
+	}
+
+	holdLock()
+	if err := db.Create(&Item{Name: "statement"}).Error; err != nil {
+		t.Errorf("Expected statement to be retried; got error: %v", err)
+	}
+
+	holdLock()
+	var attempts int
+	if err := Transaction(db.WithContext(WithTxLock(context.Background(), TxImmediate)), func(tx *gorm.DB) error {
+		attempts++
+		return tx.Create(&Item{Name: "transaction"}).Error
+	}); err != nil {
+		t.Errorf("Expected transaction to be retried; got error: %v", err)
+	}
+	if attempts != 1 {
+		// the transaction body only runs once the lock is taken
+		t.Errorf("Expected transaction body to run once, got %d", attempts)
+	}
+
+	// a deferred transaction takes the write lock at its first write, so it's busy halfway through
+	attempts = 0
+	if err := Transaction(db, func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Item{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if attempts++; attempts == 1 {
+			holdLock()
+		}
+		return tx.Create(&Item{Name: "retried transaction"}).Error
+	}); err != nil {
+		t.Errorf("Expected transaction to be retried; got error: %v", err)
+	}
+	if attempts < 2 {
+		t.Errorf("Expected transaction body to run again, got %d attempts", attempts)
+	}
+
+	noRetry, err := gorm.Open(Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	noRetryDB, _ := noRetry.DB()
+	defer noRetryDB.Close()
+
+	holdLock()
+	if err := noRetry.Create(&Item{Name: "no retry"}).Error; !IsBusy(err) {
+		t.Errorf("Expected SQLITE_BUSY without retry policy; got error: %v", err)
+	}
+
+	var count int64
+	db.Model(&Item{}).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 items, got %d", count)
+	}
+}
@@ -3,7 +3,9 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"io/fs"
 	"strconv"
+	"strings"
 
 	"gorm.io/gorm/callbacks"
 
@@ -13,6 +15,7 @@ import (
 	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 	_ "modernc.org/sqlite"
+	"modernc.org/sqlite/vfs"
 )
 
 // DriverName is the default driver name for SQLite.
@@ -22,20 +25,108 @@ type Dialector struct {
 	DriverName string
 	DSN        string
 	Conn       gorm.ConnPool
+	// Pragmas are applied to every pooled connection, they are ignored when Conn is set.
+	Pragmas *Pragmas
+	// ReadPoolSize enables a single-connection writer pool plus a separate pool of read-only connections,
+	// queries outside of transactions are sent to the readers. It's meant for file databases in WAL mode,
+	// Initialize rejects it with Conn or a private in-memory database.
+	ReadPoolSize int
+	// TxLock is the default lock mode of transactions, it can be overridden per call with WithTxLock.
+	TxLock TxLock
+	// Retry enables retrying statements failing with SQLITE_BUSY or SQLITE_LOCKED, use Transaction to
+	// retry whole transactions.
+	Retry *RetryPolicy
+	// Functions are made available to SQL on every connection. They are registered with the modernc.org/sqlite
+	// driver for the whole process, not just this Dialector: every database opened through the driver sees them,
+	// and opening another Dialector with a function of the same name replaces its implementation everywhere.
+	Functions map[string]*Function
+	// Collations are registered like Functions, process-wide with the last implementation of a name winning,
+	// and can be used in the collate settings of column and index tags. See CollateUnicodeNoCase and CollateNatural.
+	Collations map[string]func(left, right string) int
+	// OnConnect runs on each new physical connection before the pool uses it, e.g. to ATTACH databases
+	// or create temp tables. An error discards the connection, and fails Initialize for the first one.
+	OnConnect func(ctx context.Context, conn *sql.Conn) error
+	// Attach maps aliases to the database files attached to every connection, models use them by
+	// qualifying their table name, e.g. "inventory.items".
+	Attach map[string]string
+	// ErrorCodes adds errors matched by the errors of Translate with the given extended or primary result
+	// code, on top of the built-in ones.
+	ErrorCodes map[int]error
+	// ErrorTranslator runs first in Translate, a nil result keeps the *Error, e.g. to turn the message
+	// of RAISE(ABORT, 'insufficient_funds') in a trigger into a domain error.
+	ErrorTranslator func(err *Error) error
+	// ForeignKeyDiagnostics runs a failed statement again to find the rows violating foreign keys, and
+	// reports them in the ForeignKeyViolations of the *Error. It's meant for debugging, and needs TranslateError.
+	ForeignKeyDiagnostics bool
+	// FS serves the database file at DSN, opened read-only and immutable through a modernc.org/sqlite VFS.
+	// See OpenFS.
+	FS fs.FS
+	// ReadOnly opens the DSN with mode=ro, writes and migrations fail early with ErrReadOnly.
+	ReadOnly bool
+	// Immutable implies ReadOnly and also sets immutable=1, SQLite then skips all locking and change
+	// detection, so the file must not be modified by anyone while it's open.
+	Immutable bool
+
+	capabilities Capabilities
+	transactions *transactions
 }
 
 type Config struct {
-	DriverName string
-	DSN        string
-	Conn       gorm.ConnPool
+	DriverName            string
+	DSN                   string
+	Conn                  gorm.ConnPool
+	Pragmas               *Pragmas
+	ReadPoolSize          int
+	TxLock                TxLock
+	Retry                 *RetryPolicy
+	Functions             map[string]*Function
+	Collations            map[string]func(left, right string) int
+	OnConnect             func(ctx context.Context, conn *sql.Conn) error
+	Attach                map[string]string
+	ErrorCodes            map[int]error
+	ErrorTranslator       func(err *Error) error
+	ForeignKeyDiagnostics bool
+	FS                    fs.FS
+	ReadOnly              bool
+	Immutable             bool
 }
 
 func Open(dsn string) gorm.Dialector {
 	return &Dialector{DSN: dsn}
 }
 
+// OpenMemory opens the named in-memory database shared by all connections of the pool, it lives
+// until the *sql.DB is closed, as one connection is kept open for it.
+func OpenMemory(name string) gorm.Dialector {
+	return &Dialector{DSN: DSN{Path: name, Mode: "memory", Cache: "shared"}.String()}
+}
+
+// OpenFS opens the database file at path within fsys read-only, e.g. a reference database embedded
+// with embed.FS, without copying it to the disk first.
+func OpenFS(fsys fs.FS, path string) gorm.Dialector {
+	return &Dialector{DSN: path, FS: fsys}
+}
+
 func New(config Config) gorm.Dialector {
-	return &Dialector{DSN: config.DSN, DriverName: config.DriverName, Conn: config.Conn}
+	return &Dialector{
+		DSN:                   config.DSN,
+		DriverName:            config.DriverName,
+		Conn:                  config.Conn,
+		Pragmas:               config.Pragmas,
+		ReadPoolSize:          config.ReadPoolSize,
+		TxLock:                config.TxLock,
+		Retry:                 config.Retry,
+		Functions:             config.Functions,
+		Collations:            config.Collations,
+		OnConnect:             config.OnConnect,
+		Attach:                config.Attach,
+		ErrorCodes:            config.ErrorCodes,
+		ErrorTranslator:       config.ErrorTranslator,
+		ForeignKeyDiagnostics: config.ForeignKeyDiagnostics,
+		FS:                    config.FS,
+		ReadOnly:              config.ReadOnly,
+		Immutable:             config.Immutable,
+	}
 }
 
 func (dialector Dialector) Name() string {
@@ -47,32 +138,83 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 		dialector.DriverName = DriverName
 	}
 
+	if err := dialector.validateReadPool(); err != nil {
+		return err
+	}
+
+	var readPool gorm.ConnPool
 	if dialector.Conn != nil {
 		db.ConnPool = dialector.Conn
+		if sqlDB, ok := dialector.Conn.(*sql.DB); ok && dialector.Retry != nil {
+			db.ConnPool = &retryPool{DB: sqlDB, policy: *dialector.Retry, logger: db.Logger}
+		}
 	} else {
-		conn, err := sql.Open(dialector.DriverName, dialector.DSN)
-		if err != nil {
+		if dsn, err := ParseDSN(dialector.DSN); err != nil {
+			db.Logger.Warn(context.Background(), "sqlite: %v", err)
+		} else if err := dsn.Validate(); err != nil {
+			db.Logger.Warn(context.Background(), "sqlite: %v", err)
+		} else if value, ok := dsn.Pragma("foreign_keys"); ok && !isTruthy(value) &&
+			(dialector.Pragmas == nil || dialector.Pragmas.ForeignKeys == nil || !*dialector.Pragmas.ForeignKeys) {
+			db.Logger.Warn(context.Background(), "sqlite: foreign_keys is off, foreign key constraints are not enforced")
+		}
+
+		dialector.transactions = &transactions{}
+		if db.ConnPool, readPool, err = dialector.openPools(db.Logger); err != nil {
 			return err
 		}
-		db.ConnPool = conn
+		defer func() {
+			if err != nil {
+				if sqlDB, dbErr := db.DB(); dbErr == nil {
+					sqlDB.Close()
+				}
+			}
+		}()
 	}
 
-	var version string
-	if err := db.ConnPool.QueryRowContext(context.Background(), "select sqlite_version()").Scan(&version); err != nil {
+	if dialector.capabilities, err = detectCapabilities(context.Background(), db.ConnPool); err != nil {
 		return err
 	}
-	// https://www.sqlite.org/releaselog/3_35_0.html
-	if compareVersion(version, "3.35.0") >= 0 {
-		callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
-			CreateClauses:        []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"},
-			UpdateClauses:        []string{"UPDATE", "SET", "FROM", "WHERE", "RETURNING"},
-			DeleteClauses:        []string{"DELETE", "FROM", "WHERE", "RETURNING"},
-			LastInsertIDReversed: true,
-		})
-	} else {
-		callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
-			LastInsertIDReversed: true,
-		})
+
+	callbackConfig := &callbacks.Config{
+		UpdateClauses:        []string{"UPDATE", "SET", "WHERE"},
+		LastInsertIDReversed: true,
+	}
+	if dialector.capabilities.UpdateFrom {
+		callbackConfig.UpdateClauses = []string{"UPDATE", "SET", "FROM", "WHERE"}
+	}
+	if dialector.capabilities.Returning {
+		callbackConfig.CreateClauses = []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"}
+		callbackConfig.UpdateClauses = append(callbackConfig.UpdateClauses, "RETURNING")
+		callbackConfig.DeleteClauses = []string{"DELETE", "FROM", "WHERE", "RETURNING"}
+	}
+	callbacks.RegisterDefaultCallbacks(db, callbackConfig)
+
+	if readPool != nil {
+		if err := registerReadPool(db, readPool); err != nil {
+			return err
+		}
+	}
+
+	if err := registerErrorFields(db); err != nil {
+		return err
+	}
+
+	if dialector.ForeignKeyDiagnostics {
+		if err := registerForeignKeyDiagnostics(db); err != nil {
+			return err
+		}
+	}
+
+	if dialector.readOnly() {
+		if err := registerReadOnly(db); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := db.ConnPool.(*retryPool); ok {
+		if err := registerImmediateWrites(db); err != nil {
+			return err
+		}
 	}
 
 	for k, v := range dialector.ClauseBuilders() {
@@ -80,9 +222,131 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 			db.ClauseBuilders[k] = v
 		}
 	}
+
+	// db keeps its own copy of the Dialector with the detected Capabilities, the one given to gorm.Open
+	// is left untouched and can be opened again
+	if _, ok := db.Dialector.(*Dialector); ok {
+		db.Dialector = &dialector
+	} else {
+		db.Dialector = dialector
+	}
 	return
 }
 
+// Capabilities returns the features detected by Initialize.
+func (dialector Dialector) Capabilities() Capabilities {
+	return dialector.capabilities
+}
+
+// openPools opens the pool used for writes and, with ReadPoolSize, the pool used for reads.
+func (dialector Dialector) openPools(log logger.Interface) (pool, readPool gorm.ConnPool, err error) {
+	var vfsFS *vfs.FS
+	if dialector.FS != nil {
+		var name string
+		if name, vfsFS, err = vfs.New(dialector.FS); err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			if err != nil {
+				vfsFS.Close()
+			}
+		}()
+
+		dsn, err := ParseDSN(dialector.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		dsn.VFS = name
+		dialector.DSN = dsn.String()
+	}
+
+	connector, err := dialector.connector()
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB := sql.OpenDB(connector)
+	pool = sqlDB
+
+	// a named in-memory database is dropped with its last connection, which database/sql may close when idle
+	if dsn, err := ParseDSN(dialector.DSN); err == nil && dsn.Mode == "memory" {
+		sentinel, err := connector.base.Connect(context.Background())
+		if err != nil {
+			sqlDB.Close()
+			return nil, nil, err
+		}
+		connector.closers = append(connector.closers, sentinel)
+	}
+
+	if dialector.ReadPoolSize > 0 {
+		readConnector, err := dialector.connector()
+		if err != nil {
+			sqlDB.Close()
+			return nil, nil, err
+		}
+		readConnector.pragmas = append(readConnector.pragmas, "PRAGMA query_only = ON")
+
+		readDB := sql.OpenDB(readConnector)
+		readDB.SetMaxOpenConns(dialector.ReadPoolSize)
+		sqlDB.SetMaxOpenConns(1)
+		connector.closers = append(connector.closers, readDB)
+
+		readPool = readDB
+		if dialector.Retry != nil {
+			readPool = &retryPool{DB: readDB, policy: *dialector.Retry, logger: log}
+		}
+	}
+
+	if vfsFS != nil {
+		// unregistered once the pools using it are closed
+		connector.closers = append(connector.closers, vfsFS)
+	}
+
+	if dialector.Retry != nil {
+		pool = &retryPool{DB: sqlDB, policy: *dialector.Retry, logger: log}
+	}
+	return pool, readPool, nil
+}
+
+func (dialector Dialector) connector() (*connector, error) {
+	if err := registerFunctions(dialector.Functions); err != nil {
+		return nil, err
+	}
+	if err := registerCollations(dialector.Collations); err != nil {
+		return nil, err
+	}
+
+	dsn := dialector.DSN
+	if dialector.readOnly() {
+		var err error
+		if dsn, err = dialector.readOnlyDSN(); err != nil {
+			return nil, err
+		}
+	}
+
+	connector, err := newConnector(dialector.DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialector.Pragmas != nil {
+		if connector.pragmas, err = dialector.Pragmas.statements(); err != nil {
+			return nil, err
+		}
+	}
+	if dialector.readOnly() {
+		// mode=ro doesn't apply to in-memory databases
+		connector.pragmas = append(connector.pragmas, "PRAGMA query_only = ON")
+	}
+
+	if connector.txLock, err = dialector.TxLock.validate(); err != nil {
+		return nil, err
+	}
+	connector.attach = dialector.Attach
+	connector.txs = dialector.transactions
+	connector.onConnect = dialector.OnConnect
+	return connector, nil
+}
+
 func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
 	return map[string]clause.ClauseBuilder{
 		"INSERT": func(c clause.Clause, builder clause.Builder) {
@@ -142,11 +406,15 @@ func (dialector Dialector) DefaultValueOf(field *schema.Field) clause.Expression
 }
 
 func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
-	return Migrator{migrator.Migrator{Config: migrator.Config{
+	m := Migrator{migrator.Migrator{Config: migrator.Config{
 		DB:                          db,
-		Dialector:                   dialector,
+		Dialector:                   &dialector,
 		CreateIndexAfterCreateTable: true,
 	}}}
+	if dialector.readOnly() {
+		return readOnlyMigrator{m}
+	}
+	return m
 }
 
 func (dialector Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
@@ -236,13 +504,22 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 }
 
 func (dialectopr Dialector) SavePoint(tx *gorm.DB, name string) error {
-	tx.Exec("SAVEPOINT " + name)
-	return nil
+	return tx.Exec("SAVEPOINT " + quoteSavePoint(name)).Error
 }
 
 func (dialectopr Dialector) RollbackTo(tx *gorm.DB, name string) error {
-	tx.Exec("ROLLBACK TO SAVEPOINT " + name)
-	return nil
+	return tx.Exec("ROLLBACK TO SAVEPOINT " + quoteSavePoint(name)).Error
+}
+
+// Release releases the savepoint name and every savepoint created after it, keeping their changes
+// in the enclosing transaction. See ReleaseSavePoint.
+func (dialectopr Dialector) Release(tx *gorm.DB, name string) error {
+	return tx.Exec("RELEASE SAVEPOINT " + quoteSavePoint(name)).Error
+}
+
+// quoteSavePoint quotes name as a single identifier, QuoteTo would split it on dots.
+func quoteSavePoint(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
 }
 
 func compareVersion(version1, version2 string) int {
@@ -1,9 +1,18 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"gorm.io/gorm"
 	"modernc.org/sqlite"
@@ -133,3 +142,661 @@ func TestDialector(t *testing.T) {
 		})
 	}
 }
+
+func TestPragmas(t *testing.T) {
+	foreignKeys := true
+	db, err := gorm.Open(New(Config{
+		DSN: "file:pragmadatabase?mode=memory&cache=shared",
+		Pragmas: &Pragmas{
+			ForeignKeys: &foreignKeys,
+			BusyTimeout: 2 * time.Second,
+			CacheSize:   -4096,
+			TempStore:   "memory",
+		},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	defer sqlDB.Close()
+
+	// hold several connections at once, so each of them is a distinct physical connection
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get connection: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	for i, conn := range conns {
+		var fk, timeout, cacheSize, tempStore int
+		if err := conn.QueryRowContext(context.Background(), "PRAGMA foreign_keys").Scan(&fk); err != nil {
+			t.Fatalf("conn %d: %v", i, err)
+		}
+		conn.QueryRowContext(context.Background(), "PRAGMA busy_timeout").Scan(&timeout)
+		conn.QueryRowContext(context.Background(), "PRAGMA cache_size").Scan(&cacheSize)
+		conn.QueryRowContext(context.Background(), "PRAGMA temp_store").Scan(&tempStore)
+		if fk != 1 || timeout != 2000 || cacheSize != -4096 || tempStore != 2 {
+			t.Errorf("conn %d: unexpected pragmas foreign_keys=%d busy_timeout=%d cache_size=%d temp_store=%d", i, fk, timeout, cacheSize, tempStore)
+		}
+	}
+}
+
+func TestPragmasInvalid(t *testing.T) {
+	_, err := gorm.Open(New(Config{
+		DSN:     "file:pragmadatabase?mode=memory&cache=shared",
+		Pragmas: &Pragmas{JournalMode: "wal; DROP TABLE users"},
+	}), &gorm.Config{})
+	if err == nil {
+		t.Errorf("Expected Open to fail with an invalid journal_mode")
+	}
+}
+
+func TestReadPool(t *testing.T) {
+	type Item struct {
+		ID   uint
+		Name string
+	}
+
+	db, err := gorm.Open(New(Config{
+		DSN:          filepath.Join(t.TempDir(), "read_pool.db"),
+		Pragmas:      &Pragmas{JournalMode: "WAL", BusyTimeout: time.Second},
+		ReadPoolSize: 2,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&Item{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&Item{Name: "first"}).Error
+	}); err != nil {
+		t.Fatalf("failed to create in transaction: %v", err)
+	}
+	if err := db.Create(&Item{Name: "second"}).Error; err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	var items []Item
+	if err := db.Order("id").Find(&items).Error; err != nil || len(items) != 2 {
+		t.Fatalf("failed to query items: %v, %+v", err, items)
+	}
+
+	var queryOnly int
+	db.Raw("SELECT query_only FROM pragma_query_only").Scan(&queryOnly)
+	if queryOnly != 1 {
+		t.Errorf("Expected raw SELECT to use the read pool")
+	}
+	db.Transaction(func(tx *gorm.DB) error {
+		return tx.Raw("SELECT query_only FROM pragma_query_only").Scan(&queryOnly).Error
+	})
+	if queryOnly != 0 {
+		t.Errorf("Expected SELECT in a transaction to use the writer")
+	}
+}
+
+func TestReadPoolInvalid(t *testing.T) {
+	sqlDB, err := sql.Open(DriverName, filepath.Join(t.TempDir(), "read_pool_conn.db"))
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	defer sqlDB.Close()
+
+	for name, config := range map[string]Config{
+		"conn":    {Conn: sqlDB, ReadPoolSize: 2},
+		"memory":  {DSN: ":memory:", ReadPoolSize: 2},
+		"private": {DSN: "file:readpoolprivate?mode=memory", ReadPoolSize: 2},
+		"temp":    {DSN: "", ReadPoolSize: 2},
+	} {
+		if _, err := gorm.Open(New(config), &gorm.Config{}); err == nil {
+			t.Errorf("Expected Open to fail for %v", name)
+		}
+	}
+
+	db, err := gorm.Open(New(Config{DSN: "file:readpoolshared?mode=memory&cache=shared", ReadPoolSize: 2}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed for a shared in-memory database; got error: %v", err)
+	}
+	Close(db)
+}
+
+func TestTxLock(t *testing.T) {
+	db, err := gorm.Open(New(Config{
+		DSN:    filepath.Join(t.TempDir(), "tx_lock.db"),
+		TxLock: TxImmediate,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	// the default lock mode takes the write lock as soon as the transaction begins
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin: %v", tx.Error)
+	}
+	if err := db.Begin().Error; err == nil {
+		t.Errorf("Expected a second immediate transaction to fail while the first one holds the lock")
+	}
+
+	// deferred transactions don't take any lock until they read or write
+	deferred := db.WithContext(WithTxLock(context.Background(), TxDeferred)).Begin()
+	if deferred.Error != nil {
+		t.Errorf("Expected deferred transaction to begin; got error: %v", deferred.Error)
+	} else {
+		deferred.Rollback()
+	}
+	tx.Rollback()
+
+	if err := db.WithContext(WithTxLock(context.Background(), "shared")).Begin().Error; err == nil {
+		t.Errorf("Expected an invalid lock mode to fail")
+	}
+}
+
+func TestConnExtensions(t *testing.T) {
+	db, err := gorm.Open(New(Config{DSN: filepath.Join(t.TempDir(), "extensions.db"), TxLock: TxImmediate}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	db.Exec("CREATE TABLE items (name TEXT)")
+	db.Exec("INSERT INTO items VALUES ('bolt')")
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get a connection: %v", err)
+	}
+	defer conn.Close()
+
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := conn.Raw(func(dc interface{}) error {
+		serializer, ok := dc.(interface{ Serialize() ([]byte, error) })
+		if !ok {
+			return fmt.Errorf("Serialize not available on %T", dc)
+		}
+		if data, err := serializer.Serialize(); err != nil || len(data) == 0 {
+			return fmt.Errorf("failed to serialize: %v", err)
+		}
+
+		backuper, ok := dc.(interface {
+			NewBackup(string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return fmt.Errorf("NewBackup not available on %T", dc)
+		}
+		bck, err := backuper.NewBackup(backup)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if more, err = bck.Step(-1); err != nil {
+				return err
+			}
+		}
+		return bck.Finish()
+	}); err != nil {
+		t.Fatalf("failed to use the driver connection: %v", err)
+	}
+
+	backupDB, err := gorm.Open(New(Config{DSN: backup}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(backupDB)
+	var name string
+	if err := backupDB.Raw("SELECT name FROM items").Scan(&name).Error; err != nil || name != "bolt" {
+		t.Errorf("Expected the backup to hold the rows, got %q, error: %v", name, err)
+	}
+}
+
+func TestOnConnect(t *testing.T) {
+	var connects atomic.Int32
+	db, err := gorm.Open(New(Config{
+		DSN: "file:onconnectdatabase?mode=memory&cache=shared",
+		OnConnect: func(ctx context.Context, conn *sql.Conn) error {
+			connects.Add(1)
+			_, err := conn.ExecContext(ctx, "CREATE TEMP TABLE session_settings (name text)")
+			return err
+		},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get connection: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		if _, err := conn.ExecContext(context.Background(), "INSERT INTO session_settings VALUES ('x')"); err != nil {
+			t.Errorf("conn %d: Expected temp table created by OnConnect; got error: %v", i, err)
+		}
+	}
+	if connects.Load() != 3 {
+		t.Errorf("Expected OnConnect to run once per connection, got %d", connects.Load())
+	}
+
+	_, err = gorm.Open(New(Config{
+		DSN: "file:onconnectdatabase?mode=memory&cache=shared",
+		OnConnect: func(ctx context.Context, conn *sql.Conn) error {
+			return errors.New("setup failed")
+		},
+	}), &gorm.Config{})
+	if err == nil {
+		t.Errorf("Expected Open to fail when OnConnect fails")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	db, err := gorm.Open(Open("file:capabilitiesdatabase?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	caps := db.Dialector.(*Dialector).Capabilities()
+	if caps.Version == "" {
+		t.Fatalf("Expected the sqlite version to be detected")
+	}
+	// modernc.org/sqlite bundles a recent sqlite built with FTS5, RTREE and the math functions
+	if !caps.Returning || !caps.UpdateFrom || !caps.DropColumn || !caps.RenameColumn || !caps.JSON || !caps.StrictTables {
+		t.Errorf("Expected version based capabilities of %v, got %+v", caps.Version, caps)
+	}
+	if !caps.FTS5 || !caps.RTree || !caps.MathFunctions {
+		t.Errorf("Expected compile option based capabilities, got %+v", caps)
+	}
+
+	if caps != db.Migrator().(Migrator).Dialector.(*Dialector).Capabilities() {
+		t.Errorf("Expected the migrator to see the detected capabilities")
+	}
+
+	// a Dialector value works as well, and the one given to gorm.Open is left untouched
+	dialector := Dialector{DSN: "file:capabilitiesdatabase?mode=memory&cache=shared"}
+	valueDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(valueDB)
+	if valueDB.Dialector.(Dialector).Capabilities() != caps || dialector.Capabilities() != (Capabilities{}) {
+		t.Errorf("Expected the capabilities to be kept on the Dialector of the *gorm.DB only")
+	}
+	if m, ok := valueDB.Migrator().(Migrator); !ok || !m.capabilities().DropColumn {
+		t.Errorf("Expected the migrator to see the detected capabilities")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	type Reference struct {
+		ID   uint
+		Name string
+	}
+
+	path := filepath.Join(t.TempDir(), "reference.db")
+	db, err := gorm.Open(Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	if err := db.AutoMigrate(&Reference{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	db.Create(&Reference{Name: "seed"})
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	for _, config := range []Config{{DSN: path, ReadOnly: true}, {DSN: "file:" + path, Immutable: true}} {
+		db, err := gorm.Open(New(config), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Expected Open to succeed; got error: %v", err)
+		}
+
+		var references []Reference
+		if err := db.Find(&references).Error; err != nil || len(references) != 1 {
+			t.Errorf("Expected to read the seeded row, got %v, error: %v", references, err)
+		}
+
+		if err := db.Create(&Reference{Name: "new"}).Error; !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected create to fail with ErrReadOnly, got %v", err)
+		}
+		if err := db.Model(&references[0]).Update("name", "changed").Error; !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected update to fail with ErrReadOnly, got %v", err)
+		}
+		if err := db.Delete(&references[0]).Error; !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected delete to fail with ErrReadOnly, got %v", err)
+		}
+		if err := db.Migrator().AutoMigrate(&Reference{}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected AutoMigrate to fail with ErrReadOnly, got %v", err)
+		}
+		if err := db.Migrator().DropTable(&Reference{}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected DropTable to fail with ErrReadOnly, got %v", err)
+		}
+		if !db.Migrator().HasTable(&Reference{}) {
+			t.Errorf("Expected the migrator to still inspect the schema")
+		}
+		// raw statements bypass the callbacks, SQLite itself refuses them
+		if err := db.Exec("DELETE FROM `references`").Error; err == nil {
+			t.Errorf("Expected raw writes to be refused by SQLite")
+		}
+
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}
+}
+
+func TestOpenMemory(t *testing.T) {
+	db, err := gorm.Open(OpenMemory("open_memory"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxIdleConns(0)
+
+	if err := db.Exec("CREATE TABLE memory_values (value integer)").Error; err != nil {
+		t.Fatalf("failed to create table, got error: %v", err)
+	}
+	db.Exec("INSERT INTO memory_values VALUES (1)")
+
+	// every statement ran on a fresh connection, the data is kept by the sentinel connection
+	var count int
+	if err := db.Raw("SELECT count(*) FROM memory_values").Scan(&count).Error; err != nil || count != 1 {
+		t.Errorf("Expected the in-memory data to survive pool churn, got %v, error: %v", count, err)
+	}
+	if stats := sqlDB.Stats(); stats.OpenConnections != 0 {
+		t.Errorf("Expected no pooled connections, got %v", stats.OpenConnections)
+	}
+
+	sqlDB.Close()
+	db, err = gorm.Open(OpenMemory("open_memory"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ = db.DB()
+	defer sqlDB.Close()
+	if db.Migrator().HasTable("memory_values") {
+		t.Errorf("Expected the in-memory database to be dropped by Close")
+	}
+}
+
+type AttachedItem struct {
+	ID   uint
+	Name string `gorm:"index"`
+	SKU  string
+}
+
+func (AttachedItem) TableName() string {
+	return "inventory.items"
+}
+
+type AlteredAttachedItem struct {
+	ID   uint
+	Name string `gorm:"index"`
+	SKU  string `gorm:"not null;default:''"`
+}
+
+func (AlteredAttachedItem) TableName() string {
+	return "inventory.items"
+}
+
+func TestAttach(t *testing.T) {
+	dir := t.TempDir()
+	db, err := gorm.Open(New(Config{
+		DSN:    filepath.Join(dir, "main.db"),
+		Attach: map[string]string{"inventory": filepath.Join(dir, "inventory.db")},
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&AttachedItem{}); err != nil {
+		t.Fatalf("failed to migrate, got error: %v", err)
+	}
+	if err := db.Create(&AttachedItem{Name: "bolt", SKU: "B-1"}).Error; err != nil {
+		t.Fatalf("failed to create, got error: %v", err)
+	}
+
+	m := db.Migrator()
+	if !m.HasTable(&AttachedItem{}) || m.HasTable("items") {
+		t.Errorf("Expected the table to be created in the attached database only")
+	}
+	if tables, _ := m.GetTables(); !slices.Contains(tables, "inventory.items") {
+		t.Errorf("Expected GetTables to list the attached table, got %v", tables)
+	}
+	if !m.HasColumn(&AttachedItem{}, "SKU") || !m.HasIndex(&AttachedItem{}, "Name") {
+		t.Errorf("Expected the column and index of the attached table")
+	}
+	if columnTypes, err := m.ColumnTypes(&AttachedItem{}); err != nil || len(columnTypes) != 3 {
+		t.Errorf("Expected 3 column types, got %v, error: %v", len(columnTypes), err)
+	}
+
+	if err := m.RenameIndex(&AttachedItem{}, "idx_inventory_items_name", "idx_items_name"); err != nil {
+		t.Errorf("failed to rename index, got error: %v", err)
+	}
+	if !m.HasIndex(&AttachedItem{}, "idx_items_name") {
+		t.Errorf("Expected the renamed index in the attached database")
+	}
+
+	if err := db.Exec("CREATE VIEW inventory.bolts AS SELECT name FROM items WHERE name = 'bolt'").Error; err != nil {
+		t.Fatalf("failed to create view, got error: %v", err)
+	}
+
+	// recreates the table
+	if err := m.AlterColumn(&AlteredAttachedItem{}, "SKU"); err != nil {
+		t.Fatalf("failed to alter column, got error: %v", err)
+	}
+	if columnTypes, _ := m.ColumnTypes(&AttachedItem{}); len(columnTypes) != 3 || columnTypes[2].Name() != "sku" {
+		t.Errorf("Expected the columns to be kept, got %v", columnTypes)
+	} else if nullable, ok := columnTypes[2].Nullable(); !ok || nullable {
+		t.Errorf("Expected the column to be altered")
+	}
+	if tables, _ := m.GetTables(); slices.Contains(tables, "inventory.items__temp") || !slices.Contains(tables, "inventory.items") {
+		t.Errorf("Expected the table to be recreated in the attached database, got %v", tables)
+	}
+	if !m.HasIndex(&AttachedItem{}, "idx_items_name") {
+		t.Errorf("Expected the index to be recreated in the attached database")
+	}
+	var bolts int64
+	if err := db.Table("inventory.bolts").Count(&bolts).Error; err != nil || bolts != 1 {
+		t.Errorf("Expected the view to be recreated in the attached database, got %d, error: %v", bolts, err)
+	}
+
+	var item AttachedItem
+	if err := db.First(&item).Error; err != nil || item.Name != "bolt" {
+		t.Errorf("Expected the row to survive the rebuild, got %+v, error: %v", item, err)
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	type Reference struct {
+		ID   uint
+		Name string
+	}
+
+	path := filepath.Join(t.TempDir(), "reference.db")
+	db, err := gorm.Open(Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	db.AutoMigrate(&Reference{})
+	db.Create([]Reference{{Name: "a"}, {Name: "b"}})
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read database file: %v", err)
+	}
+	fsys := fstest.MapFS{"data/reference.db": {Data: data}}
+
+	db, err = gorm.Open(OpenFS(fsys, "data/reference.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected OpenFS to succeed; got error: %v", err)
+	}
+	sqlDB, _ = db.DB()
+	defer sqlDB.Close()
+
+	var references []Reference
+	if err := db.Order("id").Find(&references).Error; err != nil || len(references) != 2 || references[1].Name != "b" {
+		t.Errorf("Expected to read the rows from the fs.FS, got %v, error: %v", references, err)
+	}
+	if err := db.Create(&Reference{Name: "c"}).Error; !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected create to fail with ErrReadOnly, got %v", err)
+	}
+
+	if _, err := gorm.Open(OpenFS(fsys, "data/missing.db"), &gorm.Config{}); err == nil {
+		t.Errorf("Expected OpenFS to fail for a missing file")
+	}
+}
+
+func TestClose(t *testing.T) {
+	type Event struct {
+		ID   uint
+		Name string
+	}
+
+	path := filepath.Join(t.TempDir(), "close.db")
+	db, err := gorm.Open(New(Config{DSN: path, Pragmas: &Pragmas{JournalMode: "WAL"}}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	db.AutoMigrate(&Event{})
+	db.Create(&Event{Name: "first"})
+
+	started := make(chan struct{})
+	go db.Transaction(func(tx *gorm.DB) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return tx.Create(&Event{Name: "in flight"}).Error
+	})
+	<-started
+
+	if err := Close(db); err != nil {
+		t.Fatalf("Expected Close to succeed; got error: %v", err)
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() != 0 {
+		t.Errorf("Expected the WAL to be truncated, got %v bytes", info.Size())
+	}
+
+	db, err = gorm.Open(Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+
+	var count int64
+	db.Model(&Event{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected Close to wait for the transaction in flight, got %v rows", count)
+	}
+}
+
+func TestCloseContext(t *testing.T) {
+	dialector := New(Config{DSN: filepath.Join(t.TempDir(), "close_context.db")})
+	first, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	second, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+
+	tx := second.Begin()
+	defer tx.Rollback()
+
+	// the transactions of a *gorm.DB are counted on its own
+	if err := Close(first); err != nil {
+		t.Fatalf("Expected Close to ignore the transactions of another *gorm.DB; got error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := CloseContext(ctx, second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected CloseContext to give up waiting for the transaction, got %v", err)
+	}
+}
+
+func TestSavePoint(t *testing.T) {
+	type Entry struct {
+		ID   uint
+		Name string
+	}
+
+	db, err := gorm.Open(OpenMemory("savepoint"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Expected Open to succeed; got error: %v", err)
+	}
+	defer Close(db)
+	db.AutoMigrate(&Entry{})
+
+	var releases int
+	db.Callback().Raw().After("gorm:raw").Register("test:count_release", func(db *gorm.DB) {
+		if strings.HasPrefix(db.Statement.SQL.String(), "RELEASE SAVEPOINT") {
+			releases++
+		}
+	})
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.SavePoint("odd `name`.x").Error; err != nil {
+			t.Errorf("Expected SavePoint to quote the name, got error: %v", err)
+		}
+		tx.Create(&Entry{Name: "rolled back"})
+		if err := tx.RollbackTo("odd `name`.x").Error; err != nil {
+			t.Errorf("Expected RollbackTo to succeed, got error: %v", err)
+		}
+		if err := ReleaseSavePoint(tx, "odd `name`.x"); err != nil {
+			t.Errorf("Expected ReleaseSavePoint to succeed, got error: %v", err)
+		}
+
+		if err := db.Dialector.(*Dialector).RollbackTo(tx, "missing"); err == nil {
+			t.Errorf("Expected RollbackTo to report a missing savepoint")
+		}
+
+		for i := 0; i < 10; i++ {
+			if err := Transaction(tx, func(tx *gorm.DB) error {
+				return tx.Create(&Entry{Name: fmt.Sprintf("nested %d", i)}).Error
+			}); err != nil {
+				return err
+			}
+		}
+		Transaction(tx, func(tx *gorm.DB) error {
+			tx.Create(&Entry{Name: "failed"})
+			return errors.New("failed")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the transaction to succeed, got error: %v", err)
+	}
+
+	var names []string
+	db.Model(&Entry{}).Order("id").Pluck("name", &names)
+	if len(names) != 10 || names[0] != "nested 0" {
+		t.Errorf("Expected only the successful nested transactions to be committed, got %v", names)
+	}
+	if releases != 12 {
+		t.Errorf("Expected every savepoint to be released, got %v releases", releases)
+	}
+}
@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"hash/maphash"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// TxLock is the locking mode used to begin a transaction.
+// See https://www.sqlite.org/lang_transaction.html#deferred_immediate_and_exclusive_transactions
+type TxLock string
+
+const (
+	TxDeferred  TxLock = "DEFERRED"
+	TxImmediate TxLock = "IMMEDIATE"
+	TxExclusive TxLock = "EXCLUSIVE"
+)
+
+type txLockKey struct{}
+
+// WithTxLock returns a context that makes transactions begun with it use the given lock mode, e.g.
+//
+//	db.WithContext(sqlite.WithTxLock(ctx, sqlite.TxImmediate)).Transaction(func(tx *gorm.DB) error { ... })
+func WithTxLock(ctx context.Context, lock TxLock) context.Context {
+	return context.WithValue(ctx, txLockKey{}, lock)
+}
+
+func (lock TxLock) validate() (TxLock, error) {
+	switch upper := TxLock(strings.ToUpper(string(lock))); upper {
+	case "", TxDeferred, TxImmediate, TxExclusive:
+		return upper, nil
+	}
+	return "", fmt.Errorf("invalid transaction lock mode %q", string(lock))
+}
+
+// beginTx begins a transaction with an explicit lock mode, the lock set on ctx takes precedence over the default one.
+// It falls back to the driver when no lock mode is requested.
+func beginTx(ctx context.Context, conn driver.Conn, lock TxLock, opts driver.TxOptions) (driver.Tx, error) {
+	if ctxLock, ok := ctx.Value(txLockKey{}).(TxLock); ok {
+		lock = ctxLock
+	}
+
+	lock, err := lock.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if lock == "" || opts.ReadOnly {
+		if beginner, ok := conn.(driver.ConnBeginTx); ok {
+			return beginner.BeginTx(ctx, opts)
+		}
+		return conn.Begin()
+	}
+
+	if err := execConn(ctx, conn, "BEGIN "+string(lock)); err != nil {
+		return nil, err
+	}
+	return &tx{conn: conn}, nil
+}
+
+type tx struct {
+	conn driver.Conn
+}
+
+func (t *tx) Commit() error {
+	return execConn(context.Background(), t.conn, "COMMIT")
+}
+
+func (t *tx) Rollback() error {
+	return execConn(context.Background(), t.conn, "ROLLBACK")
+}
+
+// ReleaseSavePoint releases a savepoint created with db.SavePoint, its changes are kept and committed
+// with the enclosing transaction. Nested transactions run with Transaction release theirs on success.
+func ReleaseSavePoint(db *gorm.DB, name string) error {
+	releaser, ok := db.Dialector.(interface {
+		Release(tx *gorm.DB, name string) error
+	})
+	if !ok {
+		return gorm.ErrUnsupportedDriver
+	}
+	return releaser.Release(db, name)
+}
+
+// nestedTransaction runs fc within a savepoint like db.Transaction does, but releases the savepoint on
+// success, so long chains of nested transactions don't keep all of them until the outer commit.
+func nestedTransaction(db *gorm.DB, fc func(tx *gorm.DB) error) (err error) {
+	if db.DisableNestedTransaction {
+		return fc(db.Session(&gorm.Session{}))
+	}
+
+	name := fmt.Sprintf("sp%d", new(maphash.Hash).Sum64())
+	if err = db.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		// rollback on panic, on error of fc and when the release fails, then drop the savepoint
+		if panicked || err != nil {
+			db.RollbackTo(name)
+			ReleaseSavePoint(db, name)
+		}
+	}()
+
+	if err = fc(db.Session(&gorm.Session{})); err == nil {
+		err = ReleaseSavePoint(db, name)
+	}
+	panicked = false
+	return err
+}